@@ -2,6 +2,7 @@ package gophercloud
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 )
 
@@ -82,6 +83,9 @@ type ErrUnexpectedResponseCode struct {
 	Expected []int
 	Actual   int
 	Body     []byte
+	// ResponseHeader holds the headers of the response, e.g. to read the
+	// Retry-After header of a 429 or 503.
+	ResponseHeader http.Header
 }
 
 func (e ErrUnexpectedResponseCode) Error() string {
@@ -122,6 +126,13 @@ type ErrDefault408 struct {
 	ErrUnexpectedResponseCode
 }
 
+// ErrDefault409 is the error type for a 409 HTTP response code. It is only
+// returned by services whose ErrorContext implements Err409er; other services
+// return an ErrUnexpectedResponseCode.
+type ErrDefault409 struct {
+	ErrUnexpectedResponseCode
+}
+
 // ErrDefault429 is the default error type returned on a 429 HTTP response code.
 type ErrDefault429 struct {
 	ErrUnexpectedResponseCode
@@ -163,6 +174,13 @@ func (e ErrDefault405) Error() string {
 func (e ErrDefault408) Error() string {
 	return "The server timed out waiting for the request"
 }
+func (e ErrDefault409) Error() string {
+	e.DefaultErrString = fmt.Sprintf(
+		"Request conflicts with the current state of the resource: [%s %s], error message: %s",
+		e.Method, e.URL, e.Body,
+	)
+	return e.choseErrString()
+}
 func (e ErrDefault429) Error() string {
 	return "Too many requests have been sent in a given amount of time. Pause" +
 		" requests, wait up to one minute, and try again."
@@ -211,6 +229,12 @@ type Err408er interface {
 	Error408(ErrUnexpectedResponseCode) error
 }
 
+// Err409er is the interface resource error types implement to override the error message
+// from a 409 error.
+type Err409er interface {
+	Error409(ErrUnexpectedResponseCode) error
+}
+
 // Err429er is the interface resource error types implement to override the error message
 // from a 429 error.
 type Err429er interface {
@@ -0,0 +1,87 @@
+// Package polling implements the poll loop shared by the waiters of the
+// orchestration packages.
+package polling
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// Poll calls check every interval until it reports done or fails. A
+// gophercloud.ErrDefault429 from check is not a failure: the next call is
+// delayed as long as its Retry-After header asks. Poll returns ctx.Err() if
+// ctx is done first, and the error returned by timedOut if check is not done
+// within timeout; a timeout of zero or less waits indefinitely.
+func Poll(ctx context.Context, interval, timeout time.Duration, check func() (bool, error), timedOut func() error) error {
+	start := time.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		done, err := check()
+		if err != nil {
+			limited, ok := err.(gophercloud.ErrDefault429)
+			if !ok {
+				return err
+			}
+			// The service is rate limiting the polls: wait as long as it
+			// asks to.
+			delay := RetryAfter(limited.ResponseHeader, interval)
+			if timeout > 0 && time.Since(start)+delay > timeout {
+				return err
+			}
+			if err := Sleep(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+		if done {
+			return nil
+		}
+
+		if timeout > 0 && time.Since(start)+interval > timeout {
+			return timedOut()
+		}
+
+		if err := Sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Sleep sleeps for d, returning ctx.Err() early if ctx is done first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryAfter returns the delay requested by the Retry-After header of a
+// response, given either in seconds or as an HTTP date. fallback is returned
+// if the header is missing or invalid.
+func RetryAfter(h http.Header, fallback time.Duration) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return fallback
+}
@@ -0,0 +1,53 @@
+/*
+Package softwareconfig provides operations for managing Heat software configs.
+A software config contains the configuration data (a script, Puppet manifest,
+and so on) that a software deployment applies to a server.
+
+Example to Create a Software Config
+
+    createOpts := softwareconfig.CreateOpts{
+        Name:   "hello-config",
+        Group:  "script",
+        Config: "#!/bin/sh\necho \"Hello, $foo\"",
+        Inputs: []softwareconfig.Input{
+            {
+                Name: "foo",
+                Type: "String",
+            },
+        },
+        Outputs: []softwareconfig.Output{
+            {
+                Name: "result",
+            },
+        },
+    }
+
+    config, err := softwareconfig.Create(client, createOpts).Extract()
+    if err != nil {
+        panic(err)
+    }
+
+Example to List Software Configs
+
+    pages, err := softwareconfig.List(client, nil).AllPages()
+    if err != nil {
+        panic(err)
+    }
+
+    configs, err := softwareconfig.ExtractConfigs(pages)
+    if err != nil {
+        panic(err)
+    }
+
+    for _, config := range configs {
+        fmt.Printf("%+v\n", config)
+    }
+
+Example to Delete a Software Config
+
+    err := softwareconfig.Delete(client, "ddee7aca-aa32-4335-8265-d436b20db4f1").ExtractErr()
+    if err != nil {
+        panic(err)
+    }
+*/
+package softwareconfig
@@ -0,0 +1,101 @@
+package softwareconfig
+
+import (
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
+)
+
+// CreateOptsBuilder is the interface options structs have to satisfy in order
+// to be used in the Create operation in this package.
+type CreateOptsBuilder interface {
+	ToSoftwareConfigCreateMap() (map[string]interface{}, error)
+}
+
+// CreateOpts is the common options struct used in this package's Create
+// operation.
+type CreateOpts struct {
+	// Name is the name of the software config.
+	Name string `json:"name" required:"true"`
+	// Group is the namespace that groups this config by which tool will
+	// deploy it, e.g. script, puppet or Heat::Ungrouped.
+	Group string `json:"group,omitempty"`
+	// Config is the configuration script or manifest which specifies what
+	// configuration is performed.
+	Config string `json:"config,omitempty"`
+	// Inputs is the schema of the inputs that the config consumes.
+	Inputs []Input `json:"inputs,omitempty"`
+	// Outputs is the schema of the outputs that the config produces.
+	Outputs []Output `json:"outputs,omitempty"`
+	// Options is a map of options specific to the configuration management
+	// tool used by the config.
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// ToSoftwareConfigCreateMap casts a CreateOpts struct to a map.
+func (opts CreateOpts) ToSoftwareConfigCreateMap() (map[string]interface{}, error) {
+	return gophercloud.BuildRequestBody(opts, "")
+}
+
+// Create accepts a CreateOpts struct and creates a new software config using
+// the values provided.
+func Create(c *gophercloud.ServiceClient, opts CreateOptsBuilder) (r CreateResult) {
+	b, err := opts.ToSoftwareConfigCreateMap()
+	if err != nil {
+		r.Err = err
+		return
+	}
+	_, r.Err = c.Post(createURL(c), b, &r.Body, &gophercloud.RequestOpts{
+		OkCodes: []int{200},
+	})
+	return
+}
+
+// ListOptsBuilder allows extensions to add additional parameters to the
+// List request.
+type ListOptsBuilder interface {
+	ToSoftwareConfigListQuery() (string, error)
+}
+
+// ListOpts allows the paging of software configs through the API. Marker and
+// Limit are used for pagination.
+type ListOpts struct {
+	// The ID of the last-seen software config.
+	Marker string `q:"marker"`
+	// Integer value for the limit of values to return.
+	Limit int `q:"limit"`
+}
+
+// ToSoftwareConfigListQuery formats a ListOpts into a query string.
+func (opts ListOpts) ToSoftwareConfigListQuery() (string, error) {
+	q, err := gophercloud.BuildQueryString(opts)
+	return q.String(), err
+}
+
+// List makes a request against the API to list software configs.
+func List(c *gophercloud.ServiceClient, opts ListOptsBuilder) pagination.Pager {
+	url := listURL(c)
+	if opts != nil {
+		query, err := opts.ToSoftwareConfigListQuery()
+		if err != nil {
+			return pagination.Pager{Err: err}
+		}
+		url += query
+	}
+	return pagination.NewPager(c, url, func(r pagination.PageResult) pagination.Page {
+		p := ConfigPage{pagination.MarkerPageBase{PageResult: r}}
+		p.MarkerPageBase.Owner = p
+		return p
+	})
+}
+
+// Get retrieves the software config with the given ID.
+func Get(c *gophercloud.ServiceClient, id string) (r GetResult) {
+	_, r.Err = c.Get(getURL(c, id), &r.Body, nil)
+	return
+}
+
+// Delete deletes the software config with the given ID.
+func Delete(c *gophercloud.ServiceClient, id string) (r DeleteResult) {
+	_, r.Err = c.Delete(deleteURL(c, id), nil)
+	return
+}
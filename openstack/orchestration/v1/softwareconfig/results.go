@@ -0,0 +1,131 @@
+package softwareconfig
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
+)
+
+// Input describes an input consumed by a software config.
+type Input struct {
+	Name            string      `json:"name"`
+	Description     string      `json:"description,omitempty"`
+	Type            string      `json:"type,omitempty"`
+	Default         interface{} `json:"default,omitempty"`
+	Value           interface{} `json:"value,omitempty"`
+	ReplaceOnChange bool        `json:"replace_on_change,omitempty"`
+}
+
+// Output describes an output produced by a software config.
+type Output struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	ErrorOutput bool   `json:"error_output,omitempty"`
+}
+
+// Config represents a Heat software config.
+type Config struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Group        string                 `json:"group"`
+	Config       string                 `json:"config"`
+	Inputs       []Input                `json:"inputs"`
+	Outputs      []Output               `json:"outputs"`
+	Options      map[string]interface{} `json:"options"`
+	CreationTime time.Time              `json:"-"`
+}
+
+func (r *Config) UnmarshalJSON(b []byte) error {
+	type tmp Config
+	var s struct {
+		tmp
+		CreationTime string `json:"creation_time"`
+	}
+
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	*r = Config(s.tmp)
+
+	if s.CreationTime != "" {
+		t, err := time.Parse(time.RFC3339, s.CreationTime)
+		if err != nil {
+			t, err = time.Parse(gophercloud.RFC3339NoZ, s.CreationTime)
+			if err != nil {
+				return err
+			}
+		}
+		r.CreationTime = t
+	}
+
+	return nil
+}
+
+type commonResult struct {
+	gophercloud.Result
+}
+
+// Extract returns a pointer to a Config object and is called after a
+// Create or Get operation.
+func (r commonResult) Extract() (*Config, error) {
+	var s struct {
+		Config *Config `json:"software_config"`
+	}
+	err := r.ExtractInto(&s)
+	return s.Config, err
+}
+
+// CreateResult represents the result of a Create operation.
+type CreateResult struct {
+	commonResult
+}
+
+// GetResult represents the result of a Get operation.
+type GetResult struct {
+	commonResult
+}
+
+// DeleteResult represents the result of a Delete operation.
+type DeleteResult struct {
+	gophercloud.ErrResult
+}
+
+// ConfigPage abstracts the raw results of making a List() request against the
+// API.
+type ConfigPage struct {
+	pagination.MarkerPageBase
+}
+
+// IsEmpty returns true if a page contains no software configs.
+func (r ConfigPage) IsEmpty() (bool, error) {
+	configs, err := ExtractConfigs(r)
+	return len(configs) == 0, err
+}
+
+// LastMarker returns the last software config ID in a ListResult.
+func (r ConfigPage) LastMarker() (string, error) {
+	configs, err := ExtractConfigs(r)
+	if err != nil {
+		return "", err
+	}
+	if len(configs) == 0 {
+		return "", nil
+	}
+	return configs[len(configs)-1].ID, nil
+}
+
+// ExtractConfigs interprets the results of a single page from a List() call,
+// producing a slice of Config entities. Listed configs only contain the ID,
+// Name, Group and CreationTime fields.
+func ExtractConfigs(r pagination.Page) ([]Config, error) {
+	var s struct {
+		Configs []Config `json:"software_configs"`
+	}
+	err := (r.(ConfigPage)).ExtractInto(&s)
+	return s.Configs, err
+}
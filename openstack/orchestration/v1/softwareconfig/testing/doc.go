@@ -0,0 +1,2 @@
+// orchestration_softwareconfig_v1
+package testing
@@ -0,0 +1,191 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/softwareconfig"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+var Create_time, _ = time.Parse(time.RFC3339, "2018-06-26T07:57:17Z")
+
+// CreateRequest represents the request body of a Create request.
+const CreateRequest = `
+{
+  "name": "hello-config",
+  "group": "script",
+  "config": "#!/bin/sh -x\necho \"Writing to /tmp/$bar\"",
+  "inputs": [
+    {
+      "name": "bar",
+      "type": "String",
+      "default": "baaaaa"
+    }
+  ],
+  "outputs": [
+    {
+      "name": "result",
+      "error_output": true
+    }
+  ],
+  "options": {
+    "silent": true
+  }
+}`
+
+// ConfigOutput represents the response body of a Create or Get request.
+const ConfigOutput = `
+{
+  "software_config": {
+    "id": "ddee7aca-aa32-4335-8265-d436b20db4f1",
+    "name": "hello-config",
+    "group": "script",
+    "config": "#!/bin/sh -x\necho \"Writing to /tmp/$bar\"",
+    "inputs": [
+      {
+        "name": "bar",
+        "type": "String",
+        "default": "baaaaa",
+        "description": null,
+        "replace_on_change": false
+      }
+    ],
+    "outputs": [
+      {
+        "name": "result",
+        "type": "String",
+        "description": null,
+        "error_output": true
+      }
+    ],
+    "options": {
+      "silent": true
+    },
+    "creation_time": "2018-06-26T07:57:17Z"
+  }
+}`
+
+// ConfigExpected represents the expected object from a Create or Get request.
+var ConfigExpected = &softwareconfig.Config{
+	ID:     "ddee7aca-aa32-4335-8265-d436b20db4f1",
+	Name:   "hello-config",
+	Group:  "script",
+	Config: "#!/bin/sh -x\necho \"Writing to /tmp/$bar\"",
+	Inputs: []softwareconfig.Input{
+		{
+			Name:    "bar",
+			Type:    "String",
+			Default: "baaaaa",
+		},
+	},
+	Outputs: []softwareconfig.Output{
+		{
+			Name:        "result",
+			Type:        "String",
+			ErrorOutput: true,
+		},
+	},
+	Options:      map[string]interface{}{"silent": true},
+	CreationTime: Create_time,
+}
+
+// HandleCreateSuccessfully creates an HTTP handler at `/software_configs`
+// on the test handler mux that responds with a `Create` response.
+func HandleCreateSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/software_configs", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+		th.TestJSONRequest(t, r, CreateRequest)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+}
+
+// HandleGetSuccessfully creates an HTTP handler at `/software_configs/ddee7aca-aa32-4335-8265-d436b20db4f1`
+// on the test handler mux that responds with a `Get` response.
+func HandleGetSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/software_configs/ddee7aca-aa32-4335-8265-d436b20db4f1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+}
+
+// HandleDeleteSuccessfully creates an HTTP handler at `/software_configs/ddee7aca-aa32-4335-8265-d436b20db4f1`
+// on the test handler mux that responds with a `Delete` response.
+func HandleDeleteSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/software_configs/ddee7aca-aa32-4335-8265-d436b20db4f1", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "DELETE")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ListExpected represents the expected object from a List request.
+var ListExpected = []softwareconfig.Config{
+	{
+		ID:           "ddee7aca-aa32-4335-8265-d436b20db4f1",
+		Name:         "hello-config",
+		Group:        "script",
+		CreationTime: Create_time,
+	},
+	{
+		ID:           "8a8f0e69-07c0-4b06-9f07-1c2bb0bd5d1a",
+		Name:         "puppet-config",
+		Group:        "puppet",
+		CreationTime: Create_time,
+	},
+}
+
+// ListOutput represents the response body from a List request.
+const ListOutput = `
+{
+  "software_configs": [
+    {
+      "id": "ddee7aca-aa32-4335-8265-d436b20db4f1",
+      "name": "hello-config",
+      "group": "script",
+      "creation_time": "2018-06-26T07:57:17Z"
+    },
+    {
+      "id": "8a8f0e69-07c0-4b06-9f07-1c2bb0bd5d1a",
+      "name": "puppet-config",
+      "group": "puppet",
+      "creation_time": "2018-06-26T07:57:17Z"
+    }
+  ]
+}`
+
+// HandleListSuccessfully creates an HTTP handler at `/software_configs`
+// on the test handler mux that responds with a `List` response.
+func HandleListSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/software_configs", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		marker := r.Form.Get("marker")
+		switch marker {
+		case "":
+			fmt.Fprint(w, output)
+		case "8a8f0e69-07c0-4b06-9f07-1c2bb0bd5d1a":
+			fmt.Fprint(w, `{"software_configs":[]}`)
+		default:
+			t.Fatalf("Unexpected marker: [%s]", marker)
+		}
+	})
+}
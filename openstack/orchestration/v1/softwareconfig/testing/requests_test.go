@@ -0,0 +1,84 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/softwareconfig"
+	"github.com/gophercloud/gophercloud/pagination"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+func TestCreateConfig(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleCreateSuccessfully(t, ConfigOutput)
+
+	createOpts := softwareconfig.CreateOpts{
+		Name:   "hello-config",
+		Group:  "script",
+		Config: "#!/bin/sh -x\necho \"Writing to /tmp/$bar\"",
+		Inputs: []softwareconfig.Input{
+			{
+				Name:    "bar",
+				Type:    "String",
+				Default: "baaaaa",
+			},
+		},
+		Outputs: []softwareconfig.Output{
+			{
+				Name:        "result",
+				ErrorOutput: true,
+			},
+		},
+		Options: map[string]interface{}{"silent": true},
+	}
+	actual, err := softwareconfig.Create(fake.ServiceClient(), createOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, ConfigExpected, actual)
+}
+
+func TestCreateConfigMissingName(t *testing.T) {
+	res := softwareconfig.Create(fake.ServiceClient(), softwareconfig.CreateOpts{Group: "script"})
+	if res.Err == nil {
+		t.Fatal("expected error for missing name")
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, ConfigOutput)
+
+	actual, err := softwareconfig.Get(fake.ServiceClient(), "ddee7aca-aa32-4335-8265-d436b20db4f1").Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, ConfigExpected, actual)
+}
+
+func TestDeleteConfig(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleDeleteSuccessfully(t)
+
+	err := softwareconfig.Delete(fake.ServiceClient(), "ddee7aca-aa32-4335-8265-d436b20db4f1").ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestListConfigs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListSuccessfully(t, ListOutput)
+
+	count := 0
+	err := softwareconfig.List(fake.ServiceClient(), nil).EachPage(func(page pagination.Page) (bool, error) {
+		count++
+		actual, err := softwareconfig.ExtractConfigs(page)
+		th.AssertNoErr(t, err)
+
+		th.CheckDeepEquals(t, ListExpected, actual)
+
+		return true, nil
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, count, 1)
+}
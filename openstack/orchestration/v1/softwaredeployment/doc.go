@@ -0,0 +1,35 @@
+/*
+Package softwaredeployment provides operations for listing and retrieving
+Heat software deployments. A software deployment associates a software config
+with a server, and reports the status and outputs of applying that config.
+
+Example to List Software Deployments of a Stack
+
+    listOpts := softwaredeployment.ListOpts{
+        StackID: "49181cd6-169a-4130-9455-31185bbfc5bf",
+    }
+
+    pages, err := softwaredeployment.List(client, listOpts).AllPages()
+    if err != nil {
+        panic(err)
+    }
+
+    deployments, err := softwaredeployment.ExtractDeployments(pages)
+    if err != nil {
+        panic(err)
+    }
+
+    for _, deployment := range deployments {
+        fmt.Printf("%+v\n", deployment)
+    }
+
+Example to Get a Software Deployment
+
+    deployment, err := softwaredeployment.Get(client, "a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83").Extract()
+    if err != nil {
+        panic(err)
+    }
+
+    fmt.Println(deployment.Status, deployment.StatusReason)
+*/
+package softwaredeployment
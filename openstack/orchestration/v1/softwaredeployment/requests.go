@@ -0,0 +1,47 @@
+package softwaredeployment
+
+import (
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
+)
+
+// ListOptsBuilder allows extensions to add additional parameters to the
+// List request.
+type ListOptsBuilder interface {
+	ToSoftwareDeploymentListQuery() (string, error)
+}
+
+// ListOpts allows the filtering of software deployments through the API.
+type ListOpts struct {
+	// StackID filters the list to deployments belonging to the given stack.
+	StackID string `q:"stack_id"`
+	// ServerID filters the list to deployments of the given server.
+	ServerID string `q:"server_id"`
+}
+
+// ToSoftwareDeploymentListQuery formats a ListOpts into a query string.
+func (opts ListOpts) ToSoftwareDeploymentListQuery() (string, error) {
+	q, err := gophercloud.BuildQueryString(opts)
+	return q.String(), err
+}
+
+// List makes a request against the API to list software deployments.
+func List(c *gophercloud.ServiceClient, opts ListOptsBuilder) pagination.Pager {
+	url := listURL(c)
+	if opts != nil {
+		query, err := opts.ToSoftwareDeploymentListQuery()
+		if err != nil {
+			return pagination.Pager{Err: err}
+		}
+		url += query
+	}
+	return pagination.NewPager(c, url, func(r pagination.PageResult) pagination.Page {
+		return DeploymentPage{pagination.SinglePageBase(r)}
+	})
+}
+
+// Get retrieves the software deployment with the given ID.
+func Get(c *gophercloud.ServiceClient, id string) (r GetResult) {
+	_, r.Err = c.Get(getURL(c, id), &r.Body, nil)
+	return
+}
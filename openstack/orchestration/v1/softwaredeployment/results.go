@@ -0,0 +1,110 @@
+package softwaredeployment
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/pagination"
+)
+
+// Deployment represents a Heat software deployment.
+type Deployment struct {
+	// ID is the ID of the software deployment.
+	ID string `json:"id"`
+	// ServerID is the ID of the server the config is deployed to.
+	ServerID string `json:"server_id"`
+	// ConfigID is the ID of the software config being deployed.
+	ConfigID string `json:"config_id"`
+	// Action is the current action of the deployment (e.g. CREATE).
+	Action string `json:"action"`
+	// Status is the current status of the deployment (e.g. COMPLETE).
+	Status string `json:"status"`
+	// StatusReason is the reason for the current status.
+	StatusReason string `json:"status_reason"`
+	// InputValues are the input values passed to the config.
+	InputValues map[string]interface{} `json:"input_values"`
+	// OutputValues are the output values signalled back by the server.
+	OutputValues map[string]interface{} `json:"output_values"`
+	// CreationTime is the time the deployment was created.
+	CreationTime time.Time `json:"-"`
+	// UpdatedTime is the time the deployment was last updated.
+	UpdatedTime time.Time `json:"-"`
+}
+
+func (r *Deployment) UnmarshalJSON(b []byte) error {
+	type tmp Deployment
+	var s struct {
+		tmp
+		CreationTime string `json:"creation_time"`
+		UpdatedTime  string `json:"updated_time"`
+	}
+
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	*r = Deployment(s.tmp)
+
+	if s.CreationTime != "" {
+		t, err := time.Parse(time.RFC3339, s.CreationTime)
+		if err != nil {
+			t, err = time.Parse(gophercloud.RFC3339NoZ, s.CreationTime)
+			if err != nil {
+				return err
+			}
+		}
+		r.CreationTime = t
+	}
+
+	if s.UpdatedTime != "" {
+		t, err := time.Parse(time.RFC3339, s.UpdatedTime)
+		if err != nil {
+			t, err = time.Parse(gophercloud.RFC3339NoZ, s.UpdatedTime)
+			if err != nil {
+				return err
+			}
+		}
+		r.UpdatedTime = t
+	}
+
+	return nil
+}
+
+// DeploymentPage abstracts the raw results of making a List() request against
+// the API.
+type DeploymentPage struct {
+	pagination.SinglePageBase
+}
+
+// IsEmpty returns true if a page contains no software deployments.
+func (r DeploymentPage) IsEmpty() (bool, error) {
+	deployments, err := ExtractDeployments(r)
+	return len(deployments) == 0, err
+}
+
+// ExtractDeployments interprets the results of a single page from a List()
+// call, producing a slice of Deployment entities.
+func ExtractDeployments(r pagination.Page) ([]Deployment, error) {
+	var s struct {
+		Deployments []Deployment `json:"software_deployments"`
+	}
+	err := (r.(DeploymentPage)).ExtractInto(&s)
+	return s.Deployments, err
+}
+
+// GetResult represents the result of a Get operation.
+type GetResult struct {
+	gophercloud.Result
+}
+
+// Extract returns a pointer to a Deployment object and is called after a
+// Get operation.
+func (r GetResult) Extract() (*Deployment, error) {
+	var s struct {
+		Deployment *Deployment `json:"software_deployment"`
+	}
+	err := r.ExtractInto(&s)
+	return s.Deployment, err
+}
@@ -0,0 +1,2 @@
+// orchestration_softwaredeployment_v1
+package testing
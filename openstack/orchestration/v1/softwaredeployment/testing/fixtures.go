@@ -0,0 +1,133 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/softwaredeployment"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+var Create_time, _ = time.Parse(time.RFC3339, "2018-06-26T07:57:17Z")
+var Updated_time, _ = time.Parse(time.RFC3339, "2018-06-26T07:58:17Z")
+
+// ListExpected represents the expected object from a List request.
+var ListExpected = []softwaredeployment.Deployment{
+	{
+		ID:           "a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83",
+		ServerID:     "49181cd6-169a-4130-9455-31185bbfc5bf",
+		ConfigID:     "5e7e2e4d-2e1a-4b4a-8e9e-5cd55a0d4f3e",
+		Action:       "CREATE",
+		Status:       "COMPLETE",
+		StatusReason: "Outputs received",
+		InputValues:  map[string]interface{}{"foo": "bar"},
+		OutputValues: map[string]interface{}{
+			"deploy_stdout":      "hello",
+			"deploy_status_code": float64(0),
+		},
+		CreationTime: Create_time,
+		UpdatedTime:  Updated_time,
+	},
+	{
+		ID:           "c9a1e4b3-3a43-4e1e-9c55-2d8f8f0dd1a2",
+		ServerID:     "49181cd6-169a-4130-9455-31185bbfc5bf",
+		ConfigID:     "0b7e1de8-5bdc-4b79-8f27-b8f6f1c9d0a1",
+		Action:       "CREATE",
+		Status:       "IN_PROGRESS",
+		StatusReason: "Deploy data available",
+		InputValues:  map[string]interface{}{},
+		OutputValues: map[string]interface{}{},
+		CreationTime: Create_time,
+	},
+}
+
+// ListOutput represents the response body from a List request.
+const ListOutput = `
+{
+  "software_deployments": [
+    {
+      "id": "a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83",
+      "server_id": "49181cd6-169a-4130-9455-31185bbfc5bf",
+      "config_id": "5e7e2e4d-2e1a-4b4a-8e9e-5cd55a0d4f3e",
+      "action": "CREATE",
+      "status": "COMPLETE",
+      "status_reason": "Outputs received",
+      "input_values": {"foo": "bar"},
+      "output_values": {
+        "deploy_stdout": "hello",
+        "deploy_status_code": 0
+      },
+      "creation_time": "2018-06-26T07:57:17Z",
+      "updated_time": "2018-06-26T07:58:17Z"
+    },
+    {
+      "id": "c9a1e4b3-3a43-4e1e-9c55-2d8f8f0dd1a2",
+      "server_id": "49181cd6-169a-4130-9455-31185bbfc5bf",
+      "config_id": "0b7e1de8-5bdc-4b79-8f27-b8f6f1c9d0a1",
+      "action": "CREATE",
+      "status": "IN_PROGRESS",
+      "status_reason": "Deploy data available",
+      "input_values": {},
+      "output_values": {},
+      "creation_time": "2018-06-26T07:57:17Z",
+      "updated_time": null
+    }
+  ]
+}`
+
+// HandleListSuccessfully creates an HTTP handler at `/software_deployments`
+// on the test handler mux that responds with a `List` response.
+func HandleListSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/software_deployments", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+		th.TestFormValues(t, r, map[string]string{
+			"stack_id": "0b1771bd-9336-4f2b-ae86-a80f971faf1e",
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+}
+
+// GetExpected represents the expected object from a Get request.
+var GetExpected = &ListExpected[0]
+
+// GetOutput represents the response body from a Get request.
+const GetOutput = `
+{
+  "software_deployment": {
+    "id": "a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83",
+    "server_id": "49181cd6-169a-4130-9455-31185bbfc5bf",
+    "config_id": "5e7e2e4d-2e1a-4b4a-8e9e-5cd55a0d4f3e",
+    "action": "CREATE",
+    "status": "COMPLETE",
+    "status_reason": "Outputs received",
+    "input_values": {"foo": "bar"},
+    "output_values": {
+      "deploy_stdout": "hello",
+      "deploy_status_code": 0
+    },
+    "creation_time": "2018-06-26T07:57:17Z",
+    "updated_time": "2018-06-26T07:58:17Z"
+  }
+}`
+
+// HandleGetSuccessfully creates an HTTP handler at `/software_deployments/a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83`
+// on the test handler mux that responds with a `Get` response.
+func HandleGetSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/software_deployments/a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+}
@@ -0,0 +1,44 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/softwaredeployment"
+	"github.com/gophercloud/gophercloud/pagination"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+func TestListDeployments(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListSuccessfully(t, ListOutput)
+
+	listOpts := softwaredeployment.ListOpts{
+		StackID: "0b1771bd-9336-4f2b-ae86-a80f971faf1e",
+	}
+
+	count := 0
+	err := softwaredeployment.List(fake.ServiceClient(), listOpts).EachPage(func(page pagination.Page) (bool, error) {
+		count++
+		actual, err := softwaredeployment.ExtractDeployments(page)
+		th.AssertNoErr(t, err)
+
+		th.CheckDeepEquals(t, ListExpected, actual)
+
+		return true, nil
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, count, 1)
+}
+
+func TestGetDeployment(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetOutput)
+
+	actual, err := softwaredeployment.Get(fake.ServiceClient(), "a7f9be47-bd5e-4a0c-bf71-d5f5bb2e3a83").Extract()
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, GetExpected, actual)
+}
@@ -1,6 +1,8 @@
 package stackevents
 
 import (
+	"time"
+
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/pagination"
 )
@@ -93,6 +95,14 @@ type ListOpts struct {
 	SortKey SortKey `q:"sort_keys"`
 	// The sort direction of the event list. Which is asc (ascending) or desc (descending).
 	SortDir SortDir `q:"sort_dir"`
+	// NestedDepth includes events from nested stacks up to the given depth.
+	// Events originating from a nested stack can be identified with
+	// Event.Stack.
+	NestedDepth int `q:"nested_depth"`
+	// Since is applied client-side: events older than Since are dropped by
+	// ExtractEvents. This allows callers to ignore events they have already
+	// seen even when the server does not support filtering by time.
+	Since time.Time
 }
 
 // ToStackEventListQuery formats a ListOpts into a query string.
@@ -111,13 +121,38 @@ func List(client *gophercloud.ServiceClient, stackName, stackID string, opts Lis
 		}
 		url += query
 	}
+	var since time.Time
+	switch o := opts.(type) {
+	case ListOpts:
+		since = o.Since
+	case *ListOpts:
+		since = o.Since
+	}
 	return pagination.NewPager(client, url, func(r pagination.PageResult) pagination.Page {
-		p := EventPage{pagination.MarkerPageBase{PageResult: r}}
+		p := EventPage{MarkerPageBase: pagination.MarkerPageBase{PageResult: r}, since: since}
 		p.MarkerPageBase.Owner = p
 		return p
 	})
 }
 
+// ExtractAll retrieves every page of events for the given stack and returns
+// them as a single slice. It returns an empty slice if the stack has no events.
+func ExtractAll(client *gophercloud.ServiceClient, stackName, stackID string, opts ListOptsBuilder) ([]Event, error) {
+	events := []Event{}
+	err := List(client, stackName, stackID, opts).EachPage(func(page pagination.Page) (bool, error) {
+		pageEvents, err := ExtractEvents(page)
+		if err != nil {
+			return false, err
+		}
+		events = append(events, pageEvents...)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
 // ListResourceEventsOptsBuilder allows extensions to add additional parameters to the
 // ListResourceEvents request.
 type ListResourceEventsOptsBuilder interface {
@@ -169,7 +204,7 @@ func ListResourceEvents(client *gophercloud.ServiceClient, stackName, stackID, r
 		url += query
 	}
 	return pagination.NewPager(client, url, func(r pagination.PageResult) pagination.Page {
-		p := EventPage{pagination.MarkerPageBase{PageResult: r}}
+		p := EventPage{MarkerPageBase: pagination.MarkerPageBase{PageResult: r}}
 		p.MarkerPageBase.Owner = p
 		return p
 	})
@@ -2,6 +2,7 @@ package stackevents
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud"
@@ -28,6 +29,27 @@ type Event struct {
 	ID string `json:"id"`
 	// Properties of the stack resource.
 	ResourceProperties map[string]interface{} `json:"resource_properties"`
+	// The ID of the top-level stack. Only returned when the events were
+	// listed with ListOpts.NestedDepth.
+	RootStackID string `json:"root_stack_id"`
+}
+
+// Stack returns the name and ID of the stack the event originated from, as
+// given by the event's "stack" link. When events are listed with
+// ListOpts.NestedDepth this identifies events belonging to a nested stack.
+// Empty strings are returned if the event has no such link.
+func (r Event) Stack() (name, id string) {
+	for _, link := range r.Links {
+		if link.Rel != "stack" {
+			continue
+		}
+		parts := strings.Split(strings.TrimRight(link.Href, "/"), "/")
+		if len(parts) < 3 || parts[len(parts)-3] != "stacks" {
+			return "", ""
+		}
+		return parts[len(parts)-2], parts[len(parts)-1]
+	}
+	return "", ""
 }
 
 func (r *Event) UnmarshalJSON(b []byte) error {
@@ -78,17 +100,18 @@ func (r FindResult) Extract() ([]Event, error) {
 // data provided through the ExtractResources call.
 type EventPage struct {
 	pagination.MarkerPageBase
+	since time.Time
 }
 
 // IsEmpty returns true if a page contains no Server results.
 func (r EventPage) IsEmpty() (bool, error) {
-	events, err := ExtractEvents(r)
+	events, err := r.extract()
 	return len(events) == 0, err
 }
 
 // LastMarker returns the last stack ID in a ListResult.
 func (r EventPage) LastMarker() (string, error) {
-	events, err := ExtractEvents(r)
+	events, err := r.extract()
 	if err != nil {
 		return "", err
 	}
@@ -98,15 +121,49 @@ func (r EventPage) LastMarker() (string, error) {
 	return events[len(events)-1].ID, nil
 }
 
-// ExtractEvents interprets the results of a single page from a List() call, producing a slice of Event entities.
-func ExtractEvents(r pagination.Page) ([]Event, error) {
+// NextPageURL returns the URL of the next page of events. Heat's `next` link
+// is followed when the page has links; otherwise the URL is built from the
+// ID of the last event of the page, used as the marker.
+func (r EventPage) NextPageURL() (string, error) {
+	var s struct {
+		Links []gophercloud.Link `json:"links"`
+	}
+	if err := r.ExtractInto(&s); err != nil {
+		return "", err
+	}
+	if s.Links != nil {
+		return gophercloud.ExtractNextURL(s.Links)
+	}
+	return r.MarkerPageBase.NextPageURL()
+}
+
+// extract returns every event of the page, regardless of ListOpts.Since, so
+// that paging is not cut short by the client-side filter.
+func (r EventPage) extract() ([]Event, error) {
 	var s struct {
 		Events []Event `json:"events"`
 	}
-	err := (r.(EventPage)).ExtractInto(&s)
+	err := r.ExtractInto(&s)
 	return s.Events, err
 }
 
+// ExtractEvents interprets the results of a single page from a List() call, producing a slice of Event entities.
+func ExtractEvents(r pagination.Page) ([]Event, error) {
+	page := r.(EventPage)
+	events, err := page.extract()
+	if err != nil || page.since.IsZero() {
+		return events, err
+	}
+
+	filtered := events[:0]
+	for _, event := range events {
+		if !event.Time.Before(page.since) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered, nil
+}
+
 // ExtractResourceEvents interprets the results of a single page from a
 // ListResourceEvents() call, producing a slice of Event entities.
 func ExtractResourceEvents(page pagination.Page) ([]Event, error) {
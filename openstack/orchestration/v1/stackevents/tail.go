@@ -0,0 +1,74 @@
+package stackevents
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// Tail streams the events of a stack as they appear, similar to
+// `openstack stack event list --follow`. It polls the events every interval,
+// each poll resuming after the last event seen, and sends each event once, in
+// time order. The events already listed when Tail starts are sent first, but
+// are history: the events channel is closed when a later event of the stack
+// itself reports a terminal (*_COMPLETE or *_FAILED) state, so Tail should be
+// started before or right after the stack action it follows. It is also
+// closed when ctx is cancelled or when listing the events fails; in these two
+// cases the error is sent on the error channel first.
+func Tail(ctx context.Context, client *gophercloud.ServiceClient, stackName, stackID string, interval time.Duration) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		opts := ListOpts{SortKey: SortCreatedAt, SortDir: SortAsc}
+		history := true
+		for {
+			listed, err := ExtractAll(client, stackName, stackID, opts)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			done := false
+			for _, event := range listed {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+				// The stack's own events report its status.
+				if !history && event.PhysicalResourceID == stackID && isTerminal(event.ResourceStatus) {
+					done = true
+				}
+			}
+			if done {
+				return
+			}
+			if len(listed) > 0 {
+				opts.Marker = listed[len(listed)-1].ID
+			}
+			history = false
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				errs <- ctx.Err()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+
+	return events, errs
+}
+
+func isTerminal(status string) bool {
+	return strings.HasSuffix(status, "_COMPLETE") || strings.HasSuffix(status, "_FAILED")
+}
@@ -3,6 +3,7 @@ package testing
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -257,6 +258,143 @@ func HandleListSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// ListFirstPageOutput and ListSecondPageOutput represent the response bodies
+// of a List request that spans two pages.
+const ListFirstPageOutput = `
+{
+  "events": [
+    {
+      "resource_name": "hello_world",
+      "event_time": "2018-06-26T07:58:17Z",
+      "logical_resource_id": "hello_world",
+      "resource_status": "CREATE_IN_PROGRESS",
+      "id": "06feb26f-9298-4a9b-8749-9d770e5d577a"
+    }
+  ]
+}`
+
+const ListSecondPageOutput = `
+{
+  "events": [
+    {
+      "resource_name": "hello_world",
+      "event_time": "2018-06-26T07:59:17Z",
+      "logical_resource_id": "hello_world",
+      "resource_status": "CREATE_COMPLETE",
+      "id": "93940999-7d40-44ae-8de4-19624e7b8d18"
+    }
+  ]
+}`
+
+// HandleListPagedSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events`
+// on the test handler mux that responds with a `List` response split over two pages.
+func HandleListPagedSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		marker := r.Form.Get("marker")
+		switch marker {
+		case "":
+			fmt.Fprintf(w, ListFirstPageOutput)
+		case "06feb26f-9298-4a9b-8749-9d770e5d577a":
+			fmt.Fprintf(w, ListSecondPageOutput)
+		case "93940999-7d40-44ae-8de4-19624e7b8d18":
+			fmt.Fprintf(w, `{"events":[]}`)
+		default:
+			t.Fatalf("Unexpected marker: [%s]", marker)
+		}
+	})
+}
+
+// HandleListLinkedSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events`
+// on the test handler mux that responds with a `List` response split over two
+// pages chained by `next` links.
+func HandleListLinkedSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		self := th.Endpoint() + "stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events"
+		switch page := r.Form.Get("page"); page {
+		case "":
+			fmt.Fprintf(w, `{"events": [{"id": "06feb26f-9298-4a9b-8749-9d770e5d577a", "resource_status": "CREATE_IN_PROGRESS"}],
+				"links": [{"rel": "self", "href": "%s"}, {"rel": "next", "href": "%s?page=2"}]}`, self, self)
+		case "2":
+			fmt.Fprintf(w, `{"events": [{"id": "93940999-7d40-44ae-8de4-19624e7b8d18", "resource_status": "CREATE_COMPLETE"}],
+				"links": [{"rel": "self", "href": "%s?page=2"}]}`, self)
+		default:
+			t.Fatalf("Unexpected page: [%s]", page)
+		}
+	})
+}
+
+// ListNestedOutput represents the response body from a List request with
+// nested_depth set, where the failing event belongs to a nested stack.
+const ListNestedOutput = `
+{
+  "events": [
+    {
+      "resource_name": "server",
+      "event_time": "2018-06-26T07:58:17Z",
+      "links": [
+        {
+          "href": "http://166.78.160.107:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/hello_world-app-xt4ck2w7zpnu/a1d2cc5f-5d3c-4d1c-bd3c-2d1d3a8a3a77",
+          "rel": "stack"
+        }
+      ],
+      "logical_resource_id": "server",
+      "resource_status_reason": "Quota exceeded",
+      "resource_status": "CREATE_FAILED",
+      "root_stack_id": "49181cd6-169a-4130-9455-31185bbfc5bf",
+      "id": "d5f2f3c2-8bd5-4b5c-a1a8-0c6a5c1d8f10"
+    },
+    {
+      "resource_name": "app",
+      "event_time": "2018-06-26T07:59:17Z",
+      "links": [
+        {
+          "href": "http://166.78.160.107:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf",
+          "rel": "stack"
+        }
+      ],
+      "logical_resource_id": "app",
+      "resource_status_reason": "resources.app: Quota exceeded",
+      "resource_status": "CREATE_FAILED",
+      "root_stack_id": "49181cd6-169a-4130-9455-31185bbfc5bf",
+      "id": "7e0d7c2a-2f7b-4f6e-9d07-39b1a5d7c2e4"
+    }
+  ]
+}`
+
+// HandleListNestedSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events`
+// on the test handler mux that responds with ListNestedOutput when
+// nested_depth is requested.
+func HandleListNestedSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		if depth := r.Form.Get("nested_depth"); depth != "2" {
+			t.Fatalf("Unexpected nested_depth: [%s]", depth)
+		}
+		switch marker := r.Form.Get("marker"); marker {
+		case "":
+			fmt.Fprintf(w, ListNestedOutput)
+		case "7e0d7c2a-2f7b-4f6e-9d07-39b1a5d7c2e4":
+			fmt.Fprintf(w, `{"events":[]}`)
+		default:
+			t.Fatalf("Unexpected marker: [%s]", marker)
+		}
+	})
+}
+
 // ListResourceEventsExpected represents the expected object from a ListResourceEvents request.
 var ListResourceEventsExpected = []stackevents.Event{
 	{
@@ -448,3 +586,65 @@ func HandleGetSuccessfully(t *testing.T, output string) {
 		fmt.Fprintf(w, output)
 	})
 }
+
+// TailBatches represents the events of a stack being updated, in the batches
+// they appear in between successive polls. The first batch is the history of
+// the stack when tailing starts, ending with the completion of its creation.
+var TailBatches = [][]string{
+	{
+		`{"id": "h1", "resource_name": "hello_world", "physical_resource_id": "49181cd6-169a-4130-9455-31185bbfc5bf", "resource_status": "CREATE_IN_PROGRESS", "event_time": "2018-06-26T07:50:00Z"}`,
+		`{"id": "h2", "resource_name": "hello_world", "physical_resource_id": "49181cd6-169a-4130-9455-31185bbfc5bf", "resource_status": "CREATE_COMPLETE", "event_time": "2018-06-26T07:51:00Z"}`,
+	},
+	{
+		`{"id": "e1", "resource_name": "hello_world", "physical_resource_id": "49181cd6-169a-4130-9455-31185bbfc5bf", "resource_status": "UPDATE_IN_PROGRESS", "event_time": "2018-06-26T07:58:00Z"}`,
+		`{"id": "e2", "resource_name": "server", "physical_resource_id": "8a310d36-46fc-436f-8be4-37a696b8ac63", "resource_status": "UPDATE_IN_PROGRESS", "event_time": "2018-06-26T07:58:10Z"}`,
+	},
+	{
+		`{"id": "e3", "resource_name": "server", "physical_resource_id": "8a310d36-46fc-436f-8be4-37a696b8ac63", "resource_status": "UPDATE_COMPLETE", "event_time": "2018-06-26T07:58:20Z"}`,
+		`{"id": "e4", "resource_name": "hello_world", "physical_resource_id": "49181cd6-169a-4130-9455-31185bbfc5bf", "resource_status": "UPDATE_COMPLETE", "event_time": "2018-06-26T07:58:30Z"}`,
+	},
+}
+
+// HandleTailSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events`
+// on the test handler mux that lists the events of the given batches in time
+// order, starting after the requested marker. The next batch appears each
+// time a listing reaches the end of the events. It returns the markers
+// requested.
+func HandleTailSuccessfully(t *testing.T, batches [][]string) *[]string {
+	markers := new([]string)
+	visible := append([]string{}, batches[0]...)
+	next := 1
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		r.ParseForm()
+		th.AssertEquals(t, "created_at", r.Form.Get("sort_keys"))
+		th.AssertEquals(t, "asc", r.Form.Get("sort_dir"))
+		marker := r.Form.Get("marker")
+		*markers = append(*markers, marker)
+
+		start := 0
+		if marker != "" {
+			start = -1
+			for i, event := range visible {
+				if strings.HasPrefix(event, fmt.Sprintf(`{"id": "%s",`, marker)) {
+					start = i + 1
+				}
+			}
+			if start < 0 {
+				t.Errorf("Unexpected marker: [%s]", marker)
+				start = len(visible)
+			}
+		}
+		page := visible[start:]
+		if len(page) == 0 && next < len(batches) {
+			visible = append(visible, batches[next]...)
+			next++
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"events": [%s]}`, strings.Join(page, ","))
+	})
+	return markers
+}
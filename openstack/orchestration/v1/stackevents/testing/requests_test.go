@@ -1,7 +1,10 @@
 package testing
 
 import (
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackevents"
 	"github.com/gophercloud/gophercloud/pagination"
@@ -40,6 +43,110 @@ func TestList(t *testing.T) {
 	th.CheckEquals(t, count, 1)
 }
 
+func TestExtractAll(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListPagedSuccessfully(t)
+
+	actual, err := stackevents.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "06feb26f-9298-4a9b-8749-9d770e5d577a", actual[0].ID)
+	th.AssertEquals(t, "93940999-7d40-44ae-8de4-19624e7b8d18", actual[1].ID)
+	th.AssertEquals(t, "CREATE_COMPLETE", actual[1].ResourceStatus)
+}
+
+func TestListFollowsNextLinks(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListLinkedSuccessfully(t)
+
+	allPages, err := stackevents.List(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil).AllPages()
+	th.AssertNoErr(t, err)
+	actual, err := stackevents.ExtractEvents(allPages)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "06feb26f-9298-4a9b-8749-9d770e5d577a", actual[0].ID)
+	th.AssertEquals(t, "93940999-7d40-44ae-8de4-19624e7b8d18", actual[1].ID)
+}
+
+func TestExtractAllEmpty(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListSuccessfully(t, `{"events":[]}`)
+
+	actual, err := stackevents.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(actual))
+}
+
+func TestListSince(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListPagedSuccessfully(t)
+
+	// The first page only holds events older than Since, paging must
+	// nevertheless continue to the second page.
+	opts := stackevents.ListOpts{Since: Timestamp1.Add(30 * time.Second)}
+	pages := 0
+	var actual []stackevents.Event
+	err := stackevents.List(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", opts).EachPage(func(page pagination.Page) (bool, error) {
+		pages++
+		events, err := stackevents.ExtractEvents(page)
+		th.AssertNoErr(t, err)
+		actual = append(actual, events...)
+		return true, nil
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, pages)
+	th.AssertEquals(t, 1, len(actual))
+	th.AssertEquals(t, "93940999-7d40-44ae-8de4-19624e7b8d18", actual[0].ID)
+}
+
+func TestListMarkerAndSort(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/events", func(w http.ResponseWriter, r *http.Request) {
+		th.TestFormValues(t, r, map[string]string{
+			"marker":    "06feb26f-9298-4a9b-8749-9d770e5d577a",
+			"sort_keys": "created_at",
+			"sort_dir":  "asc",
+		})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"events":[]}`)
+	})
+
+	opts := stackevents.ListOpts{
+		Marker:  "06feb26f-9298-4a9b-8749-9d770e5d577a",
+		SortKey: stackevents.SortCreatedAt,
+		SortDir: stackevents.SortAsc,
+		Since:   Timestamp1,
+	}
+	actual, err := stackevents.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(actual))
+}
+
+func TestListNested(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t)
+
+	opts := stackevents.ListOpts{NestedDepth: 2}
+	actual, err := stackevents.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+
+	name, id := actual[0].Stack()
+	th.AssertEquals(t, "hello_world-app-xt4ck2w7zpnu", name)
+	th.AssertEquals(t, "a1d2cc5f-5d3c-4d1c-bd3c-2d1d3a8a3a77", id)
+	th.AssertEquals(t, "49181cd6-169a-4130-9455-31185bbfc5bf", actual[0].RootStackID)
+
+	name, id = actual[1].Stack()
+	th.AssertEquals(t, "hello_world", name)
+	th.AssertEquals(t, "49181cd6-169a-4130-9455-31185bbfc5bf", id)
+}
+
 func TestListResourceEvents(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
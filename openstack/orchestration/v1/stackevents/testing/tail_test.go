@@ -0,0 +1,61 @@
+package testing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackevents"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+func TestTail(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	markers := HandleTailSuccessfully(t, TailBatches)
+
+	events, errs := stackevents.Tail(context.Background(), fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", time.Millisecond)
+
+	var ids []string
+	timeout := time.After(5 * time.Second)
+	for events != nil {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			ids = append(ids, event.ID)
+		case <-timeout:
+			t.Fatal("Tail did not stop once the stack was complete")
+		}
+	}
+	th.AssertNoErr(t, <-errs)
+	th.AssertDeepEquals(t, []string{"h1", "h2", "e1", "e2", "e3", "e4"}, ids)
+	// Only the first poll lists the events from the start; the others resume
+	// after the last event seen.
+	th.AssertDeepEquals(t, []string{"", "h2", "h2", "e2", "e2", "e4"}, *markers)
+}
+
+func TestTailCancelled(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTailSuccessfully(t, TailBatches[:1])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, errs := stackevents.Tail(ctx, fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", time.Millisecond)
+
+	// The completion of the creation is history and does not stop Tail.
+	th.AssertEquals(t, "h1", (<-events).ID)
+	th.AssertEquals(t, "h2", (<-events).ID)
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		th.AssertEquals(t, false, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tail did not stop after the context was cancelled")
+	}
+	th.AssertEquals(t, context.Canceled, <-errs)
+}
@@ -0,0 +1,126 @@
+package stackresources
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// DefaultSchemaCacheSize is the number of resource type schemas a
+// SchemaCache holds when MaxEntries is not set.
+const DefaultSchemaCacheSize = 256
+
+// SchemaCache is a bounded, least-recently-used cache of resource type
+// schemas. Schemas rarely change on a running cloud, so callers that look
+// up the same types repeatedly can use a SchemaCache instead of calling
+// Schema directly. It is safe for concurrent use.
+type SchemaCache struct {
+	// MaxEntries is the maximum number of schemas held before the least
+	// recently used one is evicted. Zero means DefaultSchemaCacheSize.
+	MaxEntries int
+
+	client  *gophercloud.ServiceClient
+	ttl     time.Duration
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type schemaCacheEntry struct {
+	typeName string
+	schema   *TypeSchema
+	expires  time.Time
+}
+
+// NewSchemaCache returns a SchemaCache that fetches schemas with the given
+// client. Cached schemas are refetched once they are older than ttl; a ttl
+// of zero keeps them until they are evicted.
+func NewSchemaCache(c *gophercloud.ServiceClient, ttl time.Duration) *SchemaCache {
+	return &SchemaCache{
+		client:  c,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the schema for the given resource type, fetching it from the
+// service if it is not cached or has expired. Failed lookups are not cached.
+func (c *SchemaCache) Get(typeName string) (*TypeSchema, error) {
+	if s, ok := c.lookup(typeName); ok {
+		return s, nil
+	}
+
+	s, err := Schema(c.client, typeName).Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	c.add(typeName, s)
+	return s, nil
+}
+
+// Purge removes every schema from the cache.
+func (c *SchemaCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// Len returns the number of schemas currently cached.
+func (c *SchemaCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *SchemaCache) lookup(typeName string) (*TypeSchema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[typeName]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*schemaCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, typeName)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.schema, true
+}
+
+func (c *SchemaCache) add(typeName string, s *TypeSchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+
+	if el, ok := c.entries[typeName]; ok {
+		entry := el.Value.(*schemaCacheEntry)
+		entry.schema = s
+		entry.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[typeName] = c.order.PushFront(&schemaCacheEntry{typeName: typeName, schema: s, expires: expires})
+
+	max := c.MaxEntries
+	if max <= 0 {
+		max = DefaultSchemaCacheSize
+	}
+	for c.order.Len() > max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*schemaCacheEntry).typeName)
+	}
+}
@@ -0,0 +1,33 @@
+package stackresources
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// ErrResourceFailed is returned by WaitForStatus when a resource reaches a
+// *_FAILED status, or is rolled back, instead of the expected status.
+type ErrResourceFailed struct {
+	gophercloud.BaseError
+	ResourceName string
+	Status       string
+	Reason       string
+}
+
+func (e ErrResourceFailed) Error() string {
+	return fmt.Sprintf("Resource [%s] is in status %s: %s", e.ResourceName, e.Status, e.Reason)
+}
+
+// ErrWaitTimeout is returned by WaitForStatus when a resource does not reach
+// the expected status in time.
+type ErrWaitTimeout struct {
+	gophercloud.BaseError
+	ResourceName string
+	Status       string
+	LastStatus   string
+}
+
+func (e ErrWaitTimeout) Error() string {
+	return fmt.Sprintf("Timed out waiting for resource [%s] to reach %s, last status was %s", e.ResourceName, e.Status, e.LastStatus)
+}
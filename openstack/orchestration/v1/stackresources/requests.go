@@ -1,6 +1,8 @@
 package stackresources
 
 import (
+	"sort"
+
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/pagination"
 )
@@ -22,6 +24,15 @@ type ListOptsBuilder interface {
 type ListOpts struct {
 	// Include resources from nest stacks up to Depth levels of recursion.
 	Depth int `q:"nested_depth"`
+	// ShowHidden includes resources that Heat hides from normal listings,
+	// e.g. for complete resource inventories. Heat versions that don't know
+	// the flag ignore it and return the normal listing.
+	ShowHidden bool `q:"show_hidden"`
+	// Statuses lists resources in any of the given statuses, e.g.
+	// CREATE_FAILED. The filter is sent to Heat, which applies it
+	// server-side. ExtractAll applies it again client-side, so that Heat
+	// versions ignoring it give the same result.
+	Statuses []string `q:"status"`
 }
 
 // ToStackResourceListQuery formats a ListOpts into a query string.
@@ -41,16 +52,214 @@ func List(client *gophercloud.ServiceClient, stackName, stackID string, opts Lis
 		url += query
 	}
 	return pagination.NewPager(client, url, func(r pagination.PageResult) pagination.Page {
-		return ResourcePage{pagination.SinglePageBase(r)}
+		return ResourcePage{pagination.LinkedPageBase{PageResult: r}}
 	})
 }
 
+// ExtractAll retrieves every page of resources for the given stack and returns
+// them as a single slice. It returns an empty slice if the stack has no
+// resources, or none in the statuses given by ListOpts.Statuses.
+func ExtractAll(client *gophercloud.ServiceClient, stackName, stackID string, opts ListOptsBuilder) ([]Resource, error) {
+	pages, err := List(client, stackName, stackID, opts).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	all, err := ExtractResources(pages)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []string
+	switch o := opts.(type) {
+	case ListOpts:
+		statuses = o.Statuses
+	case *ListOpts:
+		statuses = o.Statuses
+	}
+
+	resources := []Resource{}
+	for _, resource := range all {
+		if len(statuses) == 0 || containsStatus(statuses, resource.Status) {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// listByTypeDepth is the nested_depth used by ListByType. Heat caps it to its
+// own max_nested_stack_depth, so every reachable nested stack is included.
+const listByTypeDepth = 100
+
+// ListByType returns every resource of the given type in a stack, including
+// the resources of its nested stacks. Resource types are matched exactly, as
+// they are case sensitive in Heat (e.g. "OS::Nova::Server").
+func ListByType(client *gophercloud.ServiceClient, stackName, stackID, resourceType string) ([]Resource, error) {
+	resources, err := ExtractAll(client, stackName, stackID, ListOpts{Depth: listByTypeDepth})
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []Resource{}
+	for _, resource := range resources {
+		if resource.Type == resourceType {
+			matched = append(matched, resource)
+		}
+	}
+	return matched, nil
+}
+
+// SummarizeTypes returns the number of resources of each type in a stack,
+// including the resources of its nested stacks, keyed by resource type.
+func SummarizeTypes(client *gophercloud.ServiceClient, stackName, stackID string) (map[string]int, error) {
+	resources, err := ExtractAll(client, stackName, stackID, ListOpts{Depth: listByTypeDepth})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, resource := range resources {
+		counts[resource.Type]++
+	}
+	return counts, nil
+}
+
+// PhysicalID returns the physical ID of the resource with the given logical
+// name in a stack, e.g. the UUID of the Nova server created for a server
+// resource. If the stack has no such resource of its own, the resources of
+// its nested stacks are searched. A gophercloud.ErrResourceNotFound is
+// returned if no resource has that name, and a
+// gophercloud.ErrMultipleResourcesFound if several nested stacks have one.
+func PhysicalID(client *gophercloud.ServiceClient, stackName, stackID, logicalName string) (string, error) {
+	resource, err := Get(client, stackName, stackID, logicalName).Extract()
+	if err == nil {
+		return resource.PhysicalID, nil
+	}
+	if _, ok := err.(gophercloud.ErrDefault404); !ok {
+		return "", err
+	}
+
+	resources, err := ExtractAll(client, stackName, stackID, ListOpts{Depth: listByTypeDepth})
+	if err != nil {
+		return "", err
+	}
+
+	var matched []Resource
+	for _, resource := range resources {
+		if resource.Name == logicalName {
+			matched = append(matched, resource)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return "", gophercloud.ErrResourceNotFound{Name: logicalName, ResourceType: "stack resource"}
+	case 1:
+		return matched[0].PhysicalID, nil
+	default:
+		return "", gophercloud.ErrMultipleResourcesFound{Name: logicalName, Count: len(matched), ResourceType: "stack resource"}
+	}
+}
+
+// FindByPhysicalID returns the resource of a stack, or of one of its nested
+// stacks, whose physical ID is physicalID, e.g. to find the resource that
+// created a given Nova server. The stack the resource belongs to is given by
+// its "stack" link. A gophercloud.ErrResourceNotFound is returned if no
+// resource has that physical ID.
+func FindByPhysicalID(client *gophercloud.ServiceClient, stackName, stackID, physicalID string) (*Resource, error) {
+	notFound := gophercloud.ErrResourceNotFound{Name: physicalID, ResourceType: "stack resource"}
+	if physicalID == "" {
+		return nil, notFound
+	}
+
+	resources, err := ExtractAll(client, stackName, stackID, ListOpts{Depth: listByTypeDepth})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range resources {
+		if resource.PhysicalID == physicalID {
+			return &resource, nil
+		}
+	}
+	return nil, notFound
+}
+
+// DependencyGraph returns the dependency graph of the resources of a stack as
+// an adjacency list built from the `required_by` field of each resource. The
+// edges point from a resource to the resources that depend on it: if B
+// requires A, graph["A"] contains "B". Every resource of the stack is a key of
+// the graph, with an empty list if nothing depends on it. Resources of nested
+// stacks are not included.
+func DependencyGraph(client *gophercloud.ServiceClient, stackName, stackID string) (map[string][]string, error) {
+	resources, err := ExtractAll(client, stackName, stackID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]string, len(resources))
+	for _, resource := range resources {
+		dependents := []string{}
+		for _, r := range resource.RequiredBy {
+			if name, ok := r.(string); ok {
+				dependents = append(dependents, name)
+			}
+		}
+		sort.Strings(dependents)
+		graph[resource.Name] = dependents
+	}
+	return graph, nil
+}
+
 // Get retreives data for the given stack resource.
 func Get(c *gophercloud.ServiceClient, stackName, stackID, resourceName string) (r GetResult) {
 	_, r.Err = c.Get(getURL(c, stackName, stackID, resourceName), &r.Body, nil)
 	return
 }
 
+// GetOptsBuilder allows extensions to add additional parameters to the
+// GetWithOpts request.
+type GetOptsBuilder interface {
+	ToStackResourceGetQuery() (string, error)
+}
+
+// GetOpts allows the response of a GetWithOpts request to be tailored.
+type GetOpts struct {
+	// WithAttr names attributes of the resource to resolve and return in
+	// Attributes, e.g. "networks" for a server. Some clouds only return the
+	// attributes explicitly named.
+	WithAttr []string `q:"with_attr"`
+}
+
+// ToStackResourceGetQuery formats a GetOpts into a query string.
+func (opts GetOpts) ToStackResourceGetQuery() (string, error) {
+	q, err := gophercloud.BuildQueryString(opts)
+	return q.String(), err
+}
+
+// GetWithOpts retreives data for the given stack resource, passing the given
+// options as query parameters.
+func GetWithOpts(c *gophercloud.ServiceClient, stackName, stackID, resourceName string, opts GetOptsBuilder) (r GetResult) {
+	url := getURL(c, stackName, stackID, resourceName)
+	if opts != nil {
+		query, err := opts.ToStackResourceGetQuery()
+		if err != nil {
+			r.Err = err
+			return
+		}
+		url += query
+	}
+	_, r.Err = c.Get(url, &r.Body, nil)
+	return
+}
+
 // Metadata retreives the metadata for the given stack resource.
 func Metadata(c *gophercloud.ServiceClient, stackName, stackID, resourceName string) (r MetadataResult) {
 	_, r.Err = c.Get(metadataURL(c, stackName, stackID, resourceName), &r.Body, nil)
@@ -83,7 +83,20 @@ func (r FindResult) Extract() ([]Resource, error) {
 // As OpenStack extensions may freely alter the response bodies of structures returned to the client, you may only safely access the
 // data provided through the ExtractResources call.
 type ResourcePage struct {
-	pagination.SinglePageBase
+	pagination.LinkedPageBase
+}
+
+// NextPageURL returns the URL of the next page of resources as given by the
+// page's `next` link. Listings without one consist of a single page.
+func (r ResourcePage) NextPageURL() (string, error) {
+	var s struct {
+		Links []gophercloud.Link `json:"links"`
+	}
+	err := r.ExtractInto(&s)
+	if err != nil {
+		return "", err
+	}
+	return gophercloud.ExtractNextURL(s.Links)
 }
 
 // IsEmpty returns true if a page contains no Server results.
@@ -0,0 +1,69 @@
+package testing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackresources"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+func TestSchemaCacheHit(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	count := HandleGetSchemasCounted(t, GetSchemaOutput)
+
+	cache := stackresources.NewSchemaCache(fake.ServiceClient(), 0)
+	for i := 0; i < 3; i++ {
+		actual, err := cache.Get("OS::Heat::AResourceName")
+		th.AssertNoErr(t, err)
+		th.AssertDeepEquals(t, GetSchemaExpected, actual)
+	}
+	th.AssertEquals(t, 1, *count)
+	th.AssertEquals(t, 1, cache.Len())
+}
+
+func TestSchemaCacheExpiry(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	count := HandleGetSchemasCounted(t, GetSchemaOutput)
+
+	cache := stackresources.NewSchemaCache(fake.ServiceClient(), 10*time.Millisecond)
+	_, err := cache.Get("OS::Heat::AResourceName")
+	th.AssertNoErr(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = cache.Get("OS::Heat::AResourceName")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, *count)
+}
+
+func TestSchemaCacheEviction(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	count := HandleGetSchemasCounted(t, GetSchemaOutput)
+
+	cache := stackresources.NewSchemaCache(fake.ServiceClient(), 0)
+	cache.MaxEntries = 1
+	_, err := cache.Get("OS::Heat::AResourceName")
+	th.AssertNoErr(t, err)
+	_, err = cache.Get("OS::Heat::OtherResourceName")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, cache.Len())
+
+	_, err = cache.Get("OS::Heat::AResourceName")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, *count)
+}
+
+func TestSchemaCacheErrorNotCached(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	cache := stackresources.NewSchemaCache(fake.ServiceClient(), 0)
+	_, err := cache.Get("OS::Heat::Missing")
+	if err == nil {
+		t.Fatal("expected an error for an unknown resource type")
+	}
+	th.AssertEquals(t, 0, cache.Len())
+}
@@ -1,6 +1,7 @@
 package testing
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -164,6 +165,94 @@ func HandleListSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// HandleListHiddenSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources`
+// on the test handler mux that lists a hidden resource along with a visible
+// one only when show_hidden is set.
+func HandleListHiddenSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("show_hidden") == "true" {
+			fmt.Fprintf(w, `{"resources": [{"resource_name": "hello_world"}, {"resource_name": "hidden_config"}]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"resources": [{"resource_name": "hello_world"}]}`)
+	})
+}
+
+// HandleListIgnoringStatus creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources`
+// on the test handler mux that checks the status filter is sent but, like
+// Heat versions that do not support it, lists resources of every status.
+func HandleListIgnoringStatus(t *testing.T, statuses ...string) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.CheckDeepEquals(t, statuses, r.URL.Query()["status"])
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"resources": [
+			{"resource_name": "net", "resource_status": "CREATE_COMPLETE"},
+			{"resource_name": "server", "resource_status": "CREATE_FAILED"},
+			{"resource_name": "volume", "resource_status": "CREATE_IN_PROGRESS"},
+			{"resource_name": "port", "resource_status": "CREATE_FAILED"}
+		]}`)
+	})
+}
+
+// HandleListFilteringStatus creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources`
+// on the test handler mux that lists only the resources in the statuses given
+// by the status filter, as Heat does.
+func HandleListFilteringStatus(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		resources := []map[string]string{
+			{"resource_name": "net", "resource_status": "CREATE_COMPLETE"},
+			{"resource_name": "server", "resource_status": "CREATE_FAILED"},
+			{"resource_name": "volume", "resource_status": "CREATE_IN_PROGRESS"},
+		}
+		statuses := r.URL.Query()["status"]
+		listed := []map[string]string{}
+		for _, resource := range resources {
+			for _, status := range statuses {
+				if resource["resource_status"] == status {
+					listed = append(listed, resource)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"resources": listed})
+	})
+}
+
+// HandleListLinkedSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources`
+// on the test handler mux that responds with a `List` response split over two
+// pages chained by `next` links.
+func HandleListLinkedSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		self := th.Endpoint() + "stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources"
+		switch marker := r.Form.Get("marker"); marker {
+		case "":
+			fmt.Fprintf(w, `{"resources": [{"resource_name": "hello_world", "resource_status": "CREATE_COMPLETE"}],
+				"links": [{"rel": "self", "href": "%s"}, {"rel": "next", "href": "%s?marker=hello_world"}]}`, self, self)
+		case "hello_world":
+			fmt.Fprintf(w, `{"resources": [{"resource_name": "hello_db", "resource_status": "CREATE_COMPLETE"}],
+				"links": [{"rel": "self", "href": "%s?marker=hello_world"}]}`, self)
+		default:
+			t.Fatalf("Unexpected marker: [%s]", marker)
+		}
+	})
+}
+
 // GetExpected represents the expected object from a Get request.
 var GetExpected = &stackresources.Resource{
 	Name: "wordpress_instance",
@@ -229,6 +318,80 @@ func HandleGetSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// HandleGetStatusSequence creates an HTTP handler at `/stacks/teststack/0b1771bd-9336-4f2b-ae86-a80f971faf1e/resources/wordpress_instance`
+// on the test handler mux that responds to successive `Get` requests with the
+// resource in each of the given statuses in turn, repeating the last one.
+func HandleGetStatusSequence(t *testing.T, statuses ...string) {
+	calls := 0
+	th.Mux.HandleFunc("/stacks/teststack/0b1771bd-9336-4f2b-ae86-a80f971faf1e/resources/wordpress_instance", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"resource": {"resource_name": "wordpress_instance", "resource_status": "%s", "resource_status_reason": "state changed"}}`, status)
+	})
+}
+
+// HandleGetRateLimited creates an HTTP handler at `/stacks/teststack/0b1771bd-9336-4f2b-ae86-a80f971faf1e/resources/wordpress_instance`
+// on the test handler mux that rejects the first `Get` request with a 429 and
+// the given Retry-After header, and responds to the following ones with the
+// resource in the given status. It returns the number of requests served.
+func HandleGetRateLimited(t *testing.T, retryAfter, status string) *int {
+	calls := new(int)
+	th.Mux.HandleFunc("/stacks/teststack/0b1771bd-9336-4f2b-ae86-a80f971faf1e/resources/wordpress_instance", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		*calls++
+		if *calls == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"resource": {"resource_name": "wordpress_instance", "resource_status": "%s", "resource_status_reason": "state changed"}}`, status)
+	})
+	return calls
+}
+
+// HandleGetWithAttrSuccessfully creates an HTTP handler at `/stacks/teststack/0b1771bd-9336-4f2b-ae86-a80f971faf1e/resources/wordpress_instance`
+// on the test handler mux that only returns the attributes named by the
+// with_attr query parameters.
+func HandleGetWithAttrSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/teststack/0b1771bd-9336-4f2b-ae86-a80f971faf1e/resources/wordpress_instance", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		all := map[string]interface{}{
+			"networks":      map[string]interface{}{"private": []interface{}{"10.0.0.5"}},
+			"first_address": "10.0.0.5",
+			"name":          "wordpress",
+		}
+		attributes := make(map[string]interface{})
+		for _, name := range r.URL.Query()["with_attr"] {
+			attributes[name] = all[name]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"resource": map[string]interface{}{
+				"resource_name": "wordpress_instance",
+				"attributes":    attributes,
+			},
+		})
+	})
+}
+
 // MetadataExpected represents the expected object from a Metadata request.
 var MetadataExpected = map[string]string{
 	"number": "7",
@@ -372,6 +535,25 @@ func HandleGetSchemaSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// HandleGetSchemasCounted creates HTTP handlers at `/resource_types/OS::Heat::AResourceName`
+// and `/resource_types/OS::Heat::OtherResourceName` on the test handler mux that
+// respond with a `Schema` response and count the requests received.
+func HandleGetSchemasCounted(t *testing.T, output string) *int {
+	count := 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		count++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, output)
+	}
+	th.Mux.HandleFunc("/resource_types/OS::Heat::AResourceName", handler)
+	th.Mux.HandleFunc("/resource_types/OS::Heat::OtherResourceName", handler)
+	return &count
+}
+
 // GetTemplateExpected represents the expected object from a Template request.
 var GetTemplateExpected = "{\n  \"HeatTemplateFormatVersion\": \"2012-12-12\",\n  \"Outputs\": {\n    \"private_key\": {\n      \"Description\": \"The private key if it has been saved.\",\n      \"Value\": \"{\\\"Fn::GetAtt\\\": [\\\"KeyPair\\\", \\\"private_key\\\"]}\"\n    },\n    \"public_key\": {\n      \"Description\": \"The public key.\",\n      \"Value\": \"{\\\"Fn::GetAtt\\\": [\\\"KeyPair\\\", \\\"public_key\\\"]}\"\n    }\n  },\n  \"Parameters\": {\n    \"name\": {\n      \"Description\": \"The name of the key pair.\",\n      \"Type\": \"String\"\n    },\n    \"public_key\": {\n      \"Description\": \"The optional public key. This allows users to supply the public key from a pre-existing key pair. If not supplied, a new key pair will be generated.\",\n      \"Type\": \"String\"\n    },\n    \"save_private_key\": {\n      \"AllowedValues\": [\n        \"True\",\n        \"true\",\n        \"False\",\n        \"false\"\n      ],\n      \"Default\": false,\n      \"Description\": \"True if the system should remember a generated private key; False otherwise.\",\n      \"Type\": \"String\"\n    }\n  },\n  \"Resources\": {\n    \"KeyPair\": {\n      \"Properties\": {\n        \"name\": {\n          \"Ref\": \"name\"\n        },\n        \"public_key\": {\n          \"Ref\": \"public_key\"\n        },\n        \"save_private_key\": {\n          \"Ref\": \"save_private_key\"\n        }\n      },\n      \"Type\": \"OS::Nova::KeyPair\"\n    }\n  }\n}"
 
@@ -441,3 +623,82 @@ func HandleGetTemplateSuccessfully(t *testing.T, output string) {
 		fmt.Fprintf(w, output)
 	})
 }
+
+// ListNestedOutput represents the response body from a nested List request.
+const ListNestedOutput = `
+{
+  "resources": [
+    {
+      "resource_name": "web",
+      "logical_resource_id": "web",
+      "physical_resource_id": "8c1b2e9f-5c33-4d2b-9a5a-4d1f8c3a1b11",
+      "resource_type": "OS::Nova::Server",
+      "resource_status": "CREATE_COMPLETE"
+    },
+    {
+      "resource_name": "db_group",
+      "logical_resource_id": "db_group",
+      "physical_resource_id": "0a8e3d64-3a0d-4b3c-9f3b-3a0c2a4c7e21",
+      "resource_type": "OS::Heat::ResourceGroup",
+      "resource_status": "CREATE_COMPLETE"
+    },
+    {
+      "resource_name": "0",
+      "logical_resource_id": "0",
+      "physical_resource_id": "d7e4b4a2-66a9-4c4a-8f55-0fb3e7d0b9c2",
+      "resource_type": "OS::Nova::Server",
+      "resource_status": "CREATE_COMPLETE"
+    },
+    {
+      "resource_name": "server_port",
+      "logical_resource_id": "server_port",
+      "physical_resource_id": "f5d1c0a3-9b2e-4f6d-8a7c-1e2d3c4b5a69",
+      "resource_type": "OS::Neutron::Port",
+      "resource_status": "CREATE_COMPLETE"
+    },
+    {
+      "resource_name": "legacy",
+      "logical_resource_id": "legacy",
+      "physical_resource_id": "",
+      "resource_type": "os::nova::server",
+      "resource_status": "CREATE_COMPLETE"
+    }
+  ]
+}`
+
+// HandleListNestedSuccessfully creates an HTTP handler at `/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources`
+// on the test handler mux that checks a nested listing is requested.
+func HandleListNestedSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks/hello_world/49181cd6-169a-4130-9455-31185bbfc5bf/resources", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestFormValues(t, r, map[string]string{"nested_depth": "100"})
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, output)
+	})
+}
+
+// ListDependenciesOutput represents the response body from a List request of
+// a stack where net <- subnet <- port <- server is a linear chain of
+// dependencies, and sg is required by both port and server.
+const ListDependenciesOutput = `
+{
+  "resources": [
+    {"resource_name": "net", "resource_type": "OS::Neutron::Net", "required_by": ["subnet"]},
+    {"resource_name": "subnet", "resource_type": "OS::Neutron::Subnet", "required_by": ["port"]},
+    {"resource_name": "sg", "resource_type": "OS::Neutron::SecurityGroup", "required_by": ["server", "port"]},
+    {"resource_name": "port", "resource_type": "OS::Neutron::Port", "required_by": ["server"]},
+    {"resource_name": "server", "resource_type": "OS::Nova::Server", "required_by": []}
+  ]
+}`
+
+// DependencyGraphExpected represents the expected graph built from
+// ListDependenciesOutput.
+var DependencyGraphExpected = map[string][]string{
+	"net":    {"subnet"},
+	"subnet": {"port"},
+	"sg":     {"port", "server"},
+	"port":   {"server"},
+	"server": {},
+}
@@ -4,6 +4,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackresources"
 	"github.com/gophercloud/gophercloud/pagination"
 	th "github.com/gophercloud/gophercloud/testhelper"
@@ -41,6 +42,35 @@ func TestListResources(t *testing.T) {
 	th.CheckEquals(t, count, 1)
 }
 
+func TestListResourcesShowHidden(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListHiddenSuccessfully(t)
+
+	resources, err := stackresources.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", stackresources.ListOpts{ShowHidden: true})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(resources))
+	th.AssertEquals(t, "hidden_config", resources[1].Name)
+
+	resources, err = stackresources.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, len(resources))
+}
+
+func TestGetResourceWithAttr(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetWithAttrSuccessfully(t)
+
+	opts := stackresources.GetOpts{WithAttr: []string{"networks", "first_address"}}
+	actual, err := stackresources.GetWithOpts(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", opts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, map[string]interface{}{
+		"networks":      map[string]interface{}{"private": []interface{}{"10.0.0.5"}},
+		"first_address": "10.0.0.5",
+	}, actual.Attributes)
+}
+
 func TestGetResource(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
@@ -87,6 +117,176 @@ func TestListResourceTypes(t *testing.T) {
 	th.CheckEquals(t, 1, count)
 }
 
+func TestExtractAllResources(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListSuccessfully(t, ListOutput)
+
+	actual, err := stackresources.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, ListExpected, actual)
+}
+
+func TestListFollowsNextLinks(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListLinkedSuccessfully(t)
+
+	actual, err := stackresources.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "hello_world", actual[0].Name)
+	th.AssertEquals(t, "hello_db", actual[1].Name)
+}
+
+func TestListByStatus(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListIgnoringStatus(t, "CREATE_FAILED")
+
+	opts := stackresources.ListOpts{Statuses: []string{"CREATE_FAILED"}}
+	actual, err := stackresources.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "server", actual[0].Name)
+	th.AssertEquals(t, "port", actual[1].Name)
+}
+
+func TestListByStatusAllPages(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListFilteringStatus(t)
+
+	opts := stackresources.ListOpts{Statuses: []string{"CREATE_FAILED", "CREATE_IN_PROGRESS"}}
+	pages, err := stackresources.List(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", opts).AllPages()
+	th.AssertNoErr(t, err)
+	actual, err := stackresources.ExtractResources(pages)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "server", actual[0].Name)
+	th.AssertEquals(t, "volume", actual[1].Name)
+}
+
+func TestExtractAllResourcesEmpty(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListSuccessfully(t, `{"resources":[]}`)
+
+	actual, err := stackresources.ExtractAll(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(actual))
+}
+
+func TestListByType(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t, ListNestedOutput)
+
+	actual, err := stackresources.ListByType(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "OS::Nova::Server")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "8c1b2e9f-5c33-4d2b-9a5a-4d1f8c3a1b11", actual[0].PhysicalID)
+	th.AssertEquals(t, "d7e4b4a2-66a9-4c4a-8f55-0fb3e7d0b9c2", actual[1].PhysicalID)
+
+	none, err := stackresources.ListByType(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "OS::Cinder::Volume")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(none))
+}
+
+func TestSummarizeTypes(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t, ListNestedOutput)
+
+	actual, err := stackresources.SummarizeTypes(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, map[string]int{
+		"OS::Nova::Server":        2,
+		"OS::Heat::ResourceGroup": 1,
+		"OS::Neutron::Port":       1,
+		"os::nova::server":        1,
+	}, actual)
+}
+
+func TestSummarizeTypesEmpty(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t, `{"resources":[]}`)
+
+	actual, err := stackresources.SummarizeTypes(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(actual))
+}
+
+func TestPhysicalID(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetOutput)
+
+	id, err := stackresources.PhysicalID(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "00e3a2fe-c65d-403c-9483-4db9930dd194", id)
+}
+
+func TestPhysicalIDNested(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t, ListNestedOutput)
+
+	id, err := stackresources.PhysicalID(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "server_port")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "f5d1c0a3-9b2e-4f6d-8a7c-1e2d3c4b5a69", id)
+
+	_, err = stackresources.PhysicalID(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "missing")
+	if _, ok := err.(gophercloud.ErrResourceNotFound); !ok {
+		t.Fatalf("expected ErrResourceNotFound, got %v", err)
+	}
+}
+
+func TestFindByPhysicalID(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t, ListNestedOutput)
+
+	resource, err := stackresources.FindByPhysicalID(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "d7e4b4a2-66a9-4c4a-8f55-0fb3e7d0b9c2")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "0", resource.Name)
+	th.AssertEquals(t, "OS::Nova::Server", resource.Type)
+
+	_, err = stackresources.FindByPhysicalID(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "00000000-0000-0000-0000-000000000000")
+	if _, ok := err.(gophercloud.ErrResourceNotFound); !ok {
+		t.Fatalf("expected ErrResourceNotFound, got %v", err)
+	}
+
+	_, err = stackresources.FindByPhysicalID(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "")
+	if _, ok := err.(gophercloud.ErrResourceNotFound); !ok {
+		t.Fatalf("expected ErrResourceNotFound for an empty physical ID, got %v", err)
+	}
+}
+
+func TestPhysicalIDNotFound(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListNestedSuccessfully(t, ListNestedOutput)
+
+	id, err := stackresources.PhysicalID(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf", "my_server")
+	th.AssertEquals(t, "", id)
+	if err == nil {
+		t.Fatal("expected an error for an absent logical name")
+	}
+	th.AssertEquals(t, "Unable to find stack resource with name my_server", err.Error())
+}
+
+func TestDependencyGraph(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleListSuccessfully(t, ListDependenciesOutput)
+
+	actual, err := stackresources.DependencyGraph(fake.ServiceClient(), "hello_world", "49181cd6-169a-4130-9455-31185bbfc5bf")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, DependencyGraphExpected, actual)
+}
+
 func TestGetResourceSchema(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
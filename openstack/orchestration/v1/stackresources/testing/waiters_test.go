@@ -0,0 +1,108 @@
+package testing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackresources"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+func setPollInterval(t *testing.T, d time.Duration) {
+	old := stackresources.PollInterval
+	stackresources.PollInterval = d
+	t.Cleanup(func() { stackresources.PollInterval = old })
+}
+
+func TestWaitForStatus(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, stackresources.StatusCreateInProgress, stackresources.StatusCreateInProgress, stackresources.StatusCreateComplete)
+
+	err := stackresources.WaitForStatus(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", "CREATE_COMPLETE", time.Second)
+	th.AssertNoErr(t, err)
+}
+
+func TestWaitForStatusFailed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "CREATE_IN_PROGRESS", "CREATE_FAILED")
+
+	err := stackresources.WaitForStatus(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", "CREATE_COMPLETE", time.Second)
+	failed, ok := err.(stackresources.ErrResourceFailed)
+	if !ok {
+		t.Fatalf("expected an ErrResourceFailed, got %v", err)
+	}
+	th.AssertEquals(t, "CREATE_FAILED", failed.Status)
+	th.AssertEquals(t, "state changed", failed.Reason)
+}
+
+func TestWaitForStatusRolledBack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, stackresources.StatusCreateInProgress, stackresources.StatusRollbackInProgress, stackresources.StatusRollbackComplete)
+
+	err := stackresources.WaitForStatus(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", "CREATE_COMPLETE", 0)
+	failed, ok := err.(stackresources.ErrResourceFailed)
+	if !ok {
+		t.Fatalf("expected an ErrResourceFailed, got %v", err)
+	}
+	th.AssertEquals(t, stackresources.StatusRollbackComplete, failed.Status)
+}
+
+func TestWaitForStatusOtherAction(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, stackresources.StatusCreateComplete, stackresources.StatusUpdateInProgress, stackresources.StatusUpdateComplete)
+
+	err := stackresources.WaitForStatus(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", "UPDATE_COMPLETE", time.Second)
+	th.AssertNoErr(t, err)
+}
+
+func TestWaitForStatusRateLimited(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	calls := HandleGetRateLimited(t, "0", stackresources.StatusCreateComplete)
+
+	err := stackresources.WaitForStatus(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", "CREATE_COMPLETE", time.Second)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, *calls)
+}
+
+func TestWaitForStatusTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, 10*time.Millisecond)
+	HandleGetStatusSequence(t, "CREATE_IN_PROGRESS")
+
+	err := stackresources.WaitForStatus(fake.ServiceClient(), "teststack", "0b1771bd-9336-4f2b-ae86-a80f971faf1e", "wordpress_instance", "CREATE_COMPLETE", 50*time.Millisecond)
+	timeout, ok := err.(stackresources.ErrWaitTimeout)
+	if !ok {
+		t.Fatalf("expected an ErrWaitTimeout, got %v", err)
+	}
+	th.AssertEquals(t, "CREATE_IN_PROGRESS", timeout.LastStatus)
+}
+
+func TestResourceStatusPredicates(t *testing.T) {
+	for _, tc := range []struct {
+		status   string
+		terminal bool
+		failed   bool
+	}{
+		{stackresources.StatusInitComplete, true, false},
+		{stackresources.StatusCreateInProgress, false, false},
+		{stackresources.StatusCreateComplete, true, false},
+		{stackresources.StatusUpdateFailed, true, true},
+		{stackresources.StatusDeleteInProgress, false, false},
+		{stackresources.StatusCheckFailed, true, true},
+	} {
+		th.AssertEquals(t, tc.terminal, stackresources.IsResourceTerminal(tc.status))
+		th.AssertEquals(t, tc.failed, stackresources.IsResourceFailed(tc.status))
+	}
+}
@@ -0,0 +1,101 @@
+package stackresources
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/internal/polling"
+)
+
+// Resource statuses reported by Heat.
+const (
+	StatusInitComplete       = "INIT_COMPLETE"
+	StatusCreateInProgress   = "CREATE_IN_PROGRESS"
+	StatusCreateComplete     = "CREATE_COMPLETE"
+	StatusCreateFailed       = "CREATE_FAILED"
+	StatusUpdateInProgress   = "UPDATE_IN_PROGRESS"
+	StatusUpdateComplete     = "UPDATE_COMPLETE"
+	StatusUpdateFailed       = "UPDATE_FAILED"
+	StatusDeleteInProgress   = "DELETE_IN_PROGRESS"
+	StatusDeleteComplete     = "DELETE_COMPLETE"
+	StatusDeleteFailed       = "DELETE_FAILED"
+	StatusRollbackInProgress = "ROLLBACK_IN_PROGRESS"
+	StatusRollbackComplete   = "ROLLBACK_COMPLETE"
+	StatusRollbackFailed     = "ROLLBACK_FAILED"
+	StatusSuspendInProgress  = "SUSPEND_IN_PROGRESS"
+	StatusSuspendComplete    = "SUSPEND_COMPLETE"
+	StatusSuspendFailed      = "SUSPEND_FAILED"
+	StatusResumeInProgress   = "RESUME_IN_PROGRESS"
+	StatusResumeComplete     = "RESUME_COMPLETE"
+	StatusResumeFailed       = "RESUME_FAILED"
+	StatusCheckInProgress    = "CHECK_IN_PROGRESS"
+	StatusCheckComplete      = "CHECK_COMPLETE"
+	StatusCheckFailed        = "CHECK_FAILED"
+	StatusAdoptInProgress    = "ADOPT_IN_PROGRESS"
+	StatusAdoptComplete      = "ADOPT_COMPLETE"
+	StatusAdoptFailed        = "ADOPT_FAILED"
+	StatusSnapshotInProgress = "SNAPSHOT_IN_PROGRESS"
+	StatusSnapshotComplete   = "SNAPSHOT_COMPLETE"
+	StatusSnapshotFailed     = "SNAPSHOT_FAILED"
+)
+
+// IsResourceFailed reports whether a resource status is a *_FAILED one.
+func IsResourceFailed(status string) bool {
+	return strings.HasSuffix(status, "_FAILED")
+}
+
+// IsResourceTerminal reports whether a resource status is one Heat leaves the
+// resource in until the next action, i.e. a *_COMPLETE or *_FAILED one.
+func IsResourceTerminal(status string) bool {
+	return strings.HasSuffix(status, "_COMPLETE") || IsResourceFailed(status)
+}
+
+// PollInterval is the time WaitForStatus sleeps between two consecutive polls
+// of a resource.
+var PollInterval = time.Second
+
+// WaitForStatus polls a resource of a stack until it reaches the given status,
+// e.g. to wait on a single OS::Heat::WaitCondition rather than on the whole
+// stack. It returns an ErrResourceFailed if the resource instead reaches a
+// *_FAILED status, or settles in another terminal status of the same action or
+// of a rollback, and an ErrWaitTimeout if the status is not reached within
+// timeout. A timeout of zero or less waits indefinitely.
+func WaitForStatus(c *gophercloud.ServiceClient, stackName, stackID, resourceName, status string, timeout time.Duration) error {
+	var resource *Resource
+	return polling.Poll(context.Background(), PollInterval, timeout, func() (bool, error) {
+		var err error
+		resource, err = Get(c, stackName, stackID, resourceName).Extract()
+		if err != nil {
+			return false, err
+		}
+
+		if resource.Status == status {
+			return true, nil
+		}
+		if IsResourceFailed(resource.Status) || settledElsewhere(resource.Status, status) {
+			return false, ErrResourceFailed{ResourceName: resourceName, Status: resource.Status, Reason: resource.StatusReason}
+		}
+		return false, nil
+	}, func() error {
+		return ErrWaitTimeout{ResourceName: resourceName, Status: status, LastStatus: resource.Status}
+	})
+}
+
+// settledElsewhere reports whether a resource in status will not reach target
+// without another action, i.e. status is terminal and of the action of target
+// or of a rollback.
+func settledElsewhere(status, target string) bool {
+	if !IsResourceTerminal(status) {
+		return false
+	}
+	action := statusAction(status)
+	return action == statusAction(target) || action == "ROLLBACK"
+}
+
+// statusAction returns the action of a resource status, e.g. CREATE for
+// CREATE_COMPLETE.
+func statusAction(status string) string {
+	return strings.SplitN(status, "_", 2)[0]
+}
@@ -0,0 +1,149 @@
+package stacks
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ValidateBundle validates a template, its environment and files locally,
+// before any request is made. It checks that the template and environment
+// parse, that every file referenced with `get_file`, as a provider template
+// or in the resource registry of the environment is found in files, and that
+// params, together with the parameters and parameter_defaults of the
+// environment, satisfy the parameters of the template. env and files may be
+// nil. Every problem found is returned; a nil slice means that the bundle is
+// valid.
+func ValidateBundle(template []byte, env []byte, files map[string]interface{}, params map[string]string) []error {
+	var errs []error
+
+	t := new(Template)
+	t.Bin = template
+	if err := t.Validate(); err != nil {
+		return append(errs, err)
+	}
+
+	refs := make(map[string]bool)
+	collectFileRefs(t.Parsed, ignoreIfTemplate, refs)
+
+	values := make(map[string]string)
+	if len(env) > 0 {
+		e := new(Environment)
+		e.Bin = env
+		if err := e.Validate(); err != nil {
+			errs = append(errs, err)
+		} else {
+			collectFileRefs(e.Parsed["resource_registry"], ignoreIfEnvironment, refs)
+			for _, section := range []string{"parameter_defaults", "parameters"} {
+				sectionMap, err := toStringKeys(e.Parsed[section])
+				if err != nil {
+					continue
+				}
+				for k, v := range sectionMap {
+					values[k] = fmt.Sprint(v)
+				}
+			}
+		}
+	}
+
+	missing := make([]string, 0, len(refs))
+	for ref := range refs {
+		if _, ok := files[ref]; !ok {
+			missing = append(missing, ref)
+		}
+	}
+	sort.Strings(missing)
+	for _, ref := range missing {
+		errs = append(errs, ErrFileNotFound{Reference: ref})
+	}
+
+	for k, v := range params {
+		values[k] = v
+	}
+	if problems := checkTemplateParameters(t.Parsed, values); len(problems) > 0 {
+		errs = append(errs, ErrInvalidParameters{Problems: problems})
+	}
+
+	return errs
+}
+
+// collectFileRefs walks te and records in refs the values that ignoreIf
+// considers to be references to files.
+func collectFileRefs(te interface{}, ignoreIf igFunc, refs map[string]bool) {
+	switch te.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		teMap, err := toStringKeys(te)
+		if err != nil {
+			return
+		}
+		for k, v := range teMap {
+			if !ignoreIf(k, v) {
+				refs[v.(string)] = true
+				continue
+			}
+			collectFileRefs(v, ignoreIf, refs)
+		}
+	case []interface{}:
+		for _, v := range te.([]interface{}) {
+			collectFileRefs(v, ignoreIf, refs)
+		}
+	}
+}
+
+// checkTemplateParameters checks values against the parameters section of a
+// parsed HOT or CFN template, the same way ValidateParameters does with the
+// schema returned by Heat.
+func checkTemplateParameters(parsed map[string]interface{}, values map[string]string) []string {
+	schemas := templateParameters(parsed)
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		schema, err := toStringKeys(schemas[name])
+		if err != nil {
+			continue
+		}
+		value, ok := values[name]
+		if !ok {
+			if _, hasDefault := parameterDefault(schema); !hasDefault {
+				problems = append(problems, fmt.Sprintf("missing required parameter [%s]", name))
+			}
+			continue
+		}
+		paramType, _ := schema["type"].(string)
+		if paramType == "" {
+			paramType, _ = schema["Type"].(string)
+		}
+		if !parameterTypeCompatible(paramType, value) {
+			problems = append(problems, fmt.Sprintf("parameter [%s] is not a valid %s: %q", name, paramType, value))
+		}
+	}
+	return problems
+}
+
+// templateParameters returns the parameters section of a parsed HOT or CFN
+// template, or nil if it has none.
+func templateParameters(parsed map[string]interface{}) map[string]interface{} {
+	section, ok := parsed["parameters"]
+	if !ok {
+		section = parsed["Parameters"]
+	}
+	schemas, err := toStringKeys(section)
+	if err != nil {
+		return nil
+	}
+	return schemas
+}
+
+// parameterDefault returns the default value of a parameter schema.
+func parameterDefault(schema map[string]interface{}) (interface{}, bool) {
+	if v, ok := schema["default"]; ok {
+		return v, true
+	}
+	v, ok := schema["Default"]
+	return v, ok
+}
@@ -0,0 +1,69 @@
+package stacks
+
+import (
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/testhelper"
+)
+
+const bundleTemplate = `heat_template_version: 2016-10-14
+parameters:
+  flavor:
+    type: string
+  count:
+    type: number
+  image:
+    type: string
+    default: cirros
+resources:
+  server:
+    type: server.yaml
+    properties:
+      user_data: { get_file: boot.sh }
+  volume:
+    type: OS::Cinder::Volume
+`
+
+func TestValidateBundle(t *testing.T) {
+	env := []byte(`resource_registry:
+  "My::Volume": volume.yaml
+parameter_defaults:
+  count: 2
+`)
+	files := map[string]interface{}{
+		"server.yaml": "heat_template_version: 2016-10-14",
+		"boot.sh":     "#!/bin/sh",
+		"volume.yaml": "heat_template_version: 2016-10-14",
+	}
+
+	errs := ValidateBundle([]byte(bundleTemplate), env, files, map[string]string{"flavor": "m1.small"})
+	th.AssertEquals(t, 0, len(errs))
+}
+
+func TestValidateBundleProblems(t *testing.T) {
+	env := []byte(`resource_registry:
+  "My::Volume": volume.yaml
+`)
+	files := map[string]interface{}{
+		"server.yaml": "heat_template_version: 2016-10-14",
+	}
+
+	errs := ValidateBundle([]byte(bundleTemplate), env, files, map[string]string{"count": "two"})
+	th.AssertEquals(t, 3, len(errs))
+	th.AssertDeepEquals(t, ErrFileNotFound{Reference: "boot.sh"}, errs[0])
+	th.AssertDeepEquals(t, ErrFileNotFound{Reference: "volume.yaml"}, errs[1])
+
+	invalid, ok := errs[2].(ErrInvalidParameters)
+	th.AssertEquals(t, true, ok)
+	th.AssertDeepEquals(t, []string{
+		`parameter [count] is not a valid number: "two"`,
+		"missing required parameter [flavor]",
+	}, invalid.Problems)
+}
+
+func TestValidateBundleInvalidTemplate(t *testing.T) {
+	errs := ValidateBundle([]byte(`resources: {}`), nil, nil, nil)
+	th.AssertEquals(t, 1, len(errs))
+	_, ok := errs[0].(ErrInvalidTemplateFormatVersion)
+	th.AssertEquals(t, true, ok)
+}
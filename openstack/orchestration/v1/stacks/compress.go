@@ -0,0 +1,75 @@
+package stacks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// sendFunc is the signature of the ServiceClient methods sending a body, such
+// as Post and Put.
+type sendFunc func(url string, JSONBody interface{}, JSONResponse interface{}, opts *gophercloud.RequestOpts) (*http.Response, error)
+
+// compresses reports whether opts asks for its request body to be sent
+// gzip-compressed.
+func compresses(opts interface{}) bool {
+	switch o := opts.(type) {
+	case CreateOpts:
+		return o.Compress
+	case *CreateOpts:
+		return o.Compress
+	case UpdateOpts:
+		return o.Compress
+	case *UpdateOpts:
+		return o.Compress
+	}
+	return false
+}
+
+// sendMaybeCompressed sends b with send. If compress is set, b is first sent
+// gzip-compressed with a `Content-Encoding: gzip` header, and sent again
+// uncompressed only if the server rejects the encoding with a 415.
+func sendMaybeCompressed(send sendFunc, url string, b map[string]interface{}, JSONResponse interface{}, opts *gophercloud.RequestOpts, compress bool) (*http.Response, error) {
+	if compress {
+		body, err := gzipJSON(b)
+		if err != nil {
+			return nil, err
+		}
+
+		compressed := *opts
+		compressed.MoreHeaders = map[string]string{
+			"Content-Type":     "application/json",
+			"Content-Encoding": "gzip",
+		}
+		for k, v := range opts.MoreHeaders {
+			compressed.MoreHeaders[k] = v
+		}
+
+		resp, err := send(url, bytes.NewReader(body), JSONResponse, &compressed)
+		if e, ok := err.(gophercloud.ErrUnexpectedResponseCode); !ok || e.Actual != http.StatusUnsupportedMediaType {
+			return resp, err
+		}
+	}
+	return send(url, b, JSONResponse, opts)
+}
+
+// gzipJSON returns the gzip-compressed JSON encoding of v.
+func gzipJSON(v interface{}) ([]byte, error) {
+	rendered, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(rendered); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+package stacks
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+)
+
+// pseudoParameterPrefix prefixes the parameters Heat adds to every stack,
+// such as OS::stack_id, which can never be set by the caller.
+const pseudoParameterPrefix = "OS::"
+
+// DiffParameters retrieves the current parameters of a stack and compares them
+// with the desired ones. It returns the desired parameters that the stack
+// doesn't have yet in added, the desired parameters whose value differs in
+// changed, and the current parameters missing from desired, with their
+// current value, in removed. Heat pseudo parameters such as OS::stack_id are
+// never reported as removed.
+//
+// A parameter that only has a server-side default, and is therefore absent
+// from the current set, is reported as added when it is desired.
+func DiffParameters(c *gophercloud.ServiceClient, stackName, stackID string, desired map[string]string) (added, changed, removed map[string]string, err error) {
+	stack, err := Get(c, stackName, stackID).Extract()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	added = make(map[string]string)
+	changed = make(map[string]string)
+	removed = make(map[string]string)
+
+	for k, v := range desired {
+		current, ok := stack.Parameters[k]
+		switch {
+		case !ok:
+			added[k] = v
+		case current != v:
+			changed[k] = v
+		}
+	}
+
+	for k, v := range stack.Parameters {
+		if strings.HasPrefix(k, pseudoParameterPrefix) {
+			continue
+		}
+		if _, ok := desired[k]; !ok {
+			removed[k] = v
+		}
+	}
+
+	return added, changed, removed, nil
+}
+
+// AbandonDiff lists the resources that differ between two abandon payloads,
+// by name and in sorted order.
+type AbandonDiff struct {
+	// Added are the resources only found in the second payload.
+	Added []string
+	// Removed are the resources only found in the first payload.
+	Removed []string
+	// Changed are the resources found in both payloads with different data,
+	// e.g. a different status or physical resource ID.
+	Changed []string
+}
+
+// DiffAbandonData compares the resources of two abandon payloads, as returned
+// by Abandon, e.g. to verify that a migrated stack matches the original. It
+// returns an error if the resources of either payload are malformed.
+func DiffAbandonData(a, b map[string]interface{}) (AbandonDiff, error) {
+	var diff AbandonDiff
+
+	before, err := abandonedResources(a)
+	if err != nil {
+		return diff, err
+	}
+	after, err := abandonedResources(b)
+	if err != nil {
+		return diff, err
+	}
+
+	for name, resource := range before {
+		other, ok := after[name]
+		if !ok {
+			diff.Removed = append(diff.Removed, name)
+		} else if !reflect.DeepEqual(resource, other) {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff, nil
+}
+
+// abandonedResources returns the resources of an abandon payload, keyed by
+// name.
+func abandonedResources(data map[string]interface{}) (map[string]interface{}, error) {
+	raw, ok := data["resources"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	resources, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Unexpected resources in abandon data: %v", raw)
+	}
+	return resources, nil
+}
@@ -99,6 +99,28 @@ Example to Create an Stack
     }
     fmt.Printf("Created Stack: %v", created_stack.ID)
 
+Example to Build an Environment Instead of Reading It From a File
+
+    envOpts := stacks.EnvironmentOpts{
+        Parameters: map[string]interface{}{
+            "number_of_nodes": 1,
+        },
+        ParameterDefaults: map[string]interface{}{
+            "node_flavor": "m1.small",
+        },
+    }
+
+    env, err := envOpts.ToEnvironment()
+    if err != nil {
+        panic(err)
+    }
+
+    createOpts := &stacks.CreateOpts{
+        Name:            "testing_group",
+        TemplateOpts:    template,
+        EnvironmentOpts: env,
+    }
+
 Example for Get Stack
 
     get_result := stacks.Get(client, stackName, created_stack.ID)
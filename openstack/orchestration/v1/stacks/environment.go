@@ -1,6 +1,12 @@
 package stacks
 
-import "strings"
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+)
 
 // Environment is a structure that represents stack environments
 type Environment struct {
@@ -9,9 +15,157 @@ type Environment struct {
 
 // EnvironmentSections is a map containing allowed sections in a stack environment file
 var EnvironmentSections = map[string]bool{
-	"parameters":         true,
-	"parameter_defaults": true,
-	"resource_registry":  true,
+	"parameters":            true,
+	"parameter_defaults":    true,
+	"resource_registry":     true,
+	"encrypted_param_names": true,
+	"event_sinks":           true,
+}
+
+// EnvironmentOpts is a structured representation of a stack environment. It
+// spares users from hand-writing environment files: call ToEnvironment to
+// build an Environment suitable for the EnvironmentOpts field of CreateOpts,
+// UpdateOpts, AdoptOpts or PreviewOpts.
+type EnvironmentOpts struct {
+	// Parameters are the values of parameters of the top level template.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// ParameterDefaults are default values of parameters that apply to the top
+	// level template and all nested templates.
+	ParameterDefaults map[string]interface{} `json:"parameter_defaults,omitempty"`
+	// ResourceRegistry maps resource types to other resource types or to
+	// provider templates.
+	ResourceRegistry map[string]interface{} `json:"resource_registry,omitempty"`
+	// Encrypted are sensitive parameter values. They are sent along with
+	// Parameters, and their names are listed in encrypted_param_names so that
+	// Heat stores them encrypted.
+	Encrypted map[string]interface{} `json:"-"`
+	// EncryptedParamNames lists parameters, given in Parameters or
+	// ParameterDefaults, that Heat should store encrypted. They are listed in
+	// encrypted_param_names along with the names of Encrypted.
+	EncryptedParamNames []string `json:"-"`
+}
+
+// NewEnvironment returns an empty EnvironmentOpts, to be filled with its
+// methods, e.g.:
+//
+//	opts := stacks.NewEnvironment()
+//	opts.RegisterResource("OS::Nova::Server", "my_server.yaml")
+//	opts.SetParameterDefault("flavor", "m1.small")
+//	env, err := opts.ToEnvironment()
+func NewEnvironment() *EnvironmentOpts {
+	return &EnvironmentOpts{}
+}
+
+// SetParameterDefault sets the default value of the parameter key in
+// parameter_defaults, which applies to the top level template and all nested
+// templates.
+func (opts *EnvironmentOpts) SetParameterDefault(key string, value interface{}) {
+	if opts.ParameterDefaults == nil {
+		opts.ParameterDefaults = make(map[string]interface{})
+	}
+	opts.ParameterDefaults[key] = value
+}
+
+// MapType maps the resource type typeName to the provider template at
+// providerTemplatePath in the resource registry. The provider template, like
+// any template referenced by the registry, is fetched and sent in the files of
+// the request when the environment is used.
+func (opts *EnvironmentOpts) MapType(typeName, providerTemplatePath string) {
+	if opts.ResourceRegistry == nil {
+		opts.ResourceRegistry = make(map[string]interface{})
+	}
+	opts.ResourceRegistry[typeName] = providerTemplatePath
+}
+
+// RegisterResource maps the resource type typeName in the resource registry
+// to templateRefOrFile, which is either another resource type or the path or
+// URL of a provider template. It is the same as MapType, and provider
+// templates are likewise sent in the files of the request.
+func (opts *EnvironmentOpts) RegisterResource(typeName, templateRefOrFile string) {
+	opts.MapType(typeName, templateRefOrFile)
+}
+
+// MapHook sets a breakpoint hook, such as "pre-create" or "pre-update", on the
+// resources named resourceName in the resource registry. resourceName may use
+// wildcards as supported by Heat, e.g. "server_*". Hooks set on the same
+// resources accumulate.
+func (opts *EnvironmentOpts) MapHook(resourceName, hook string) {
+	if opts.ResourceRegistry == nil {
+		opts.ResourceRegistry = make(map[string]interface{})
+	}
+	resources, ok := opts.ResourceRegistry["resources"].(map[string]interface{})
+	if !ok {
+		resources = make(map[string]interface{})
+		opts.ResourceRegistry["resources"] = resources
+	}
+	resource, ok := resources[resourceName].(map[string]interface{})
+	if !ok {
+		resource = make(map[string]interface{})
+		resources[resourceName] = resource
+	}
+
+	switch hooks := resource["hooks"].(type) {
+	case string:
+		resource["hooks"] = []interface{}{hooks, hook}
+	case []interface{}:
+		resource["hooks"] = append(hooks, hook)
+	default:
+		resource["hooks"] = hook
+	}
+}
+
+// ToEnvironmentMap assembles the environment sections of an EnvironmentOpts.
+func (opts EnvironmentOpts) ToEnvironmentMap() (map[string]interface{}, error) {
+	b, err := gophercloud.BuildRequestBody(opts, "")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, k := range opts.EncryptedParamNames {
+		if !seen[k] {
+			seen[k] = true
+			names = append(names, k)
+		}
+	}
+
+	if len(opts.Encrypted) > 0 {
+		params := make(map[string]interface{})
+		for k, v := range opts.Parameters {
+			params[k] = v
+		}
+		for k, v := range opts.Encrypted {
+			params[k] = v
+			if !seen[k] {
+				seen[k] = true
+				names = append(names, k)
+			}
+		}
+		b["parameters"] = params
+	}
+
+	if len(names) > 0 {
+		sort.Strings(names)
+		b["encrypted_param_names"] = names
+	}
+
+	return b, nil
+}
+
+// ToEnvironment serializes an EnvironmentOpts into an Environment.
+func (opts EnvironmentOpts) ToEnvironment() (*Environment, error) {
+	b, err := opts.ToEnvironmentMap()
+	if err != nil {
+		return nil, err
+	}
+	bin, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	e := new(Environment)
+	e.Bin = bin
+	return e, nil
 }
 
 // Validate validates the contents of the Environment
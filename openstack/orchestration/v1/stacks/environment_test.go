@@ -190,3 +190,159 @@ service_db:
 	env.Parse()
 	th.AssertDeepEquals(t, expectedParsed, env.Parsed)
 }
+
+func TestEnvironmentOptsToEnvironment(t *testing.T) {
+	opts := EnvironmentOpts{
+		Parameters: map[string]interface{}{
+			"flavor": "m1.small",
+		},
+		ParameterDefaults: map[string]interface{}{
+			"image": "cirros",
+		},
+		ResourceRegistry: map[string]interface{}{
+			"My::Server": "OS::Nova::Server",
+		},
+		Encrypted: map[string]interface{}{
+			"db_password": "secret",
+		},
+	}
+
+	env, err := opts.ToEnvironment()
+	th.AssertNoErr(t, err)
+
+	err = env.Parse()
+	th.AssertNoErr(t, err)
+
+	expected := map[string]interface{}{
+		"parameters": map[string]interface{}{
+			"flavor":      "m1.small",
+			"db_password": "secret",
+		},
+		"parameter_defaults": map[string]interface{}{
+			"image": "cirros",
+		},
+		"resource_registry": map[string]interface{}{
+			"My::Server": "OS::Nova::Server",
+		},
+		"encrypted_param_names": []interface{}{"db_password"},
+	}
+	th.AssertDeepEquals(t, expected, env.Parsed)
+}
+
+func TestEnvironmentOptsParameterDefaultsOnly(t *testing.T) {
+	opts := EnvironmentOpts{
+		ParameterDefaults: map[string]interface{}{
+			"image": "cirros",
+		},
+	}
+
+	b, err := opts.ToEnvironmentMap()
+	th.AssertNoErr(t, err)
+
+	expected := map[string]interface{}{
+		"parameter_defaults": map[string]interface{}{
+			"image": "cirros",
+		},
+	}
+	th.AssertDeepEquals(t, expected, b)
+}
+
+func TestEnvironmentOptsEncryptedParamNames(t *testing.T) {
+	opts := EnvironmentOpts{
+		Parameters: map[string]interface{}{
+			"admin_password": "hunter2",
+		},
+		ParameterDefaults: map[string]interface{}{
+			"api_key": "abc123",
+		},
+		Encrypted: map[string]interface{}{
+			"db_password": "secret",
+		},
+		EncryptedParamNames: []string{"api_key", "admin_password", "db_password"},
+	}
+
+	env, err := opts.ToEnvironment()
+	th.AssertNoErr(t, err)
+	err = env.Parse()
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, []interface{}{"admin_password", "api_key", "db_password"}, env.Parsed["encrypted_param_names"])
+	th.AssertDeepEquals(t, map[string]interface{}{
+		"admin_password": "hunter2",
+		"db_password":    "secret",
+	}, env.Parsed["parameters"])
+}
+
+func TestEnvironmentOptsMapTypeAndHook(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	baseurl, err := getBasePath()
+	th.AssertNoErr(t, err)
+
+	providerURL := strings.Join([]string{baseurl, "my_server.yaml"}, "/")
+	urlparsed, err := url.Parse(providerURL)
+	th.AssertNoErr(t, err)
+	th.Mux.HandleFunc(urlparsed.Path, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "heat_template_version: 2014-10-16")
+	})
+
+	opts := new(EnvironmentOpts)
+	opts.MapType("My::Server", "my_server.yaml")
+	opts.MapHook("server_*", "pre-create")
+	opts.MapHook("server_*", "pre-update")
+
+	env, err := opts.ToEnvironment()
+	th.AssertNoErr(t, err)
+	env.client = fakeClient{BaseClient: getHTTPClient()}
+	err = env.Parse()
+	th.AssertNoErr(t, err)
+	err = env.getRRFileContents(ignoreIfEnvironment)
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, map[string]string{providerURL: "heat_template_version: 2014-10-16"}, env.Files)
+	th.AssertDeepEquals(t, map[string]interface{}{
+		"My::Server": "my_server.yaml",
+		"resources": map[string]interface{}{
+			"server_*": map[string]interface{}{
+				"hooks": []interface{}{"pre-create", "pre-update"},
+			},
+		},
+	}, env.Parsed["resource_registry"])
+}
+
+func TestNewEnvironment(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	baseurl, err := getBasePath()
+	th.AssertNoErr(t, err)
+
+	providerURL := strings.Join([]string{baseurl, "my_server.yaml"}, "/")
+	urlparsed, err := url.Parse(providerURL)
+	th.AssertNoErr(t, err)
+	th.Mux.HandleFunc(urlparsed.Path, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "heat_template_version: 2014-10-16")
+	})
+
+	opts := NewEnvironment()
+	opts.RegisterResource("OS::Nova::Server", "my_server.yaml")
+	opts.SetParameterDefault("flavor", "m1.small")
+	opts.SetParameterDefault("count", 2)
+
+	env, err := opts.ToEnvironment()
+	th.AssertNoErr(t, err)
+	env.client = fakeClient{BaseClient: getHTTPClient()}
+	err = env.Parse()
+	th.AssertNoErr(t, err)
+	err = env.getRRFileContents(ignoreIfEnvironment)
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, map[string]string{providerURL: "heat_template_version: 2014-10-16"}, env.Files)
+	th.AssertDeepEquals(t, map[string]interface{}{
+		"resource_registry":  map[string]interface{}{"OS::Nova::Server": "my_server.yaml"},
+		"parameter_defaults": map[string]interface{}{"flavor": "m1.small", "count": float64(2)},
+	}, env.Parsed)
+}
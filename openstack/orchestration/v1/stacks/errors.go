@@ -1,9 +1,14 @@
 package stacks
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackevents"
 )
 
 type ErrInvalidEnvironment struct {
@@ -39,3 +44,257 @@ type ErrTemplateRequired struct {
 func (e ErrTemplateRequired) Error() string {
 	return fmt.Sprintf("Template required for this function.")
 }
+
+// ErrInvalidParameters is returned by ValidateParameters when the parameters
+// do not satisfy the parameter schema of a template.
+type ErrInvalidParameters struct {
+	gophercloud.BaseError
+	Problems []string
+}
+
+func (e ErrInvalidParameters) Error() string {
+	return fmt.Sprintf("Invalid stack parameters: %s", strings.Join(e.Problems, "; "))
+}
+
+// ErrFileNotFound is returned by ValidateBundle when a template or
+// environment references a file that is not part of the bundle.
+type ErrFileNotFound struct {
+	gophercloud.BaseError
+	Reference string
+}
+
+func (e ErrFileNotFound) Error() string {
+	return fmt.Sprintf("Referenced file [%s] is not in the files of the bundle", e.Reference)
+}
+
+// ErrPreviousTemplateUnavailable is returned by RollbackToPrevious when Heat
+// does not expose the previous template of the stack.
+type ErrPreviousTemplateUnavailable struct {
+	gophercloud.BaseError
+	StackName string
+	StackID   string
+}
+
+func (e ErrPreviousTemplateUnavailable) Error() string {
+	return fmt.Sprintf("The previous template of stack [%s/%s] is not available.", e.StackName, e.StackID)
+}
+
+// ErrOutputNotFound is returned when a stack has no output with the requested
+// key.
+type ErrOutputNotFound struct {
+	gophercloud.BaseError
+	Key string
+}
+
+func (e ErrOutputNotFound) Error() string {
+	return fmt.Sprintf("Output [%s] not found in stack.", e.Key)
+}
+
+// ErrOutputError is returned when Heat was unable to resolve the value of a
+// stack output.
+type ErrOutputError struct {
+	gophercloud.BaseError
+	Key    string
+	Reason string
+}
+
+func (e ErrOutputError) Error() string {
+	return fmt.Sprintf("Output [%s] could not be resolved: %s", e.Key, e.Reason)
+}
+
+// ErrOutputType is returned by the typed output helpers of RetrievedStack when
+// the value of an output is not of the requested type.
+type ErrOutputType struct {
+	gophercloud.BaseError
+	Key   string
+	Type  string
+	Value interface{}
+}
+
+func (e ErrOutputType) Error() string {
+	return fmt.Sprintf("Output [%s] is not a %s: %v", e.Key, e.Type, e.Value)
+}
+
+// ErrActionInProgress is returned when Heat rejects an operation with a 409
+// because another action is already in progress on the stack. Callers may
+// wait for the current action to finish and retry.
+type ErrActionInProgress struct {
+	gophercloud.ErrDefault409
+	// Action is the action currently in progress on the stack (e.g. UPDATE),
+	// if it could be determined from the error body.
+	Action string
+}
+
+func (e ErrActionInProgress) Error() string {
+	if e.Action != "" {
+		return fmt.Sprintf("Stack already has an action (%s) in progress.", e.Action)
+	}
+	return "Stack already has an action in progress."
+}
+
+// actionInProgressRe matches the action from Heat's ActionInProgress message,
+// e.g. "Stack foo already has an action (UPDATE) in progress."
+var actionInProgressRe = regexp.MustCompile(`action \((\w+)\) in progress`)
+
+// heatFault represents the error body returned by Heat.
+type heatFault struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// stackErrorContext is used as the ErrorContext of stack requests in order to
+// turn Heat specific failures into typed errors.
+type stackErrorContext struct{}
+
+func (stackErrorContext) Error() string {
+	return "stack request failed"
+}
+
+// Error409 distinguishes an ActionInProgress conflict from other conflicts.
+func (stackErrorContext) Error409(e gophercloud.ErrUnexpectedResponseCode) error {
+	err := gophercloud.ErrDefault409{ErrUnexpectedResponseCode: e}
+
+	var fault heatFault
+	if json.Unmarshal(e.Body, &fault) != nil || fault.Error.Type != "ActionInProgress" {
+		return err
+	}
+
+	inProgress := ErrActionInProgress{ErrDefault409: err}
+	if m := actionInProgressRe.FindStringSubmatch(fault.Error.Message); m != nil {
+		inProgress.Action = m[1]
+	}
+	return inProgress
+}
+
+// ErrStackFailed is returned by the waiters in this package when a stack
+// reaches a *_FAILED status, or a create or adopt settles in a status other
+// than its target, such as ROLLBACK_COMPLETE.
+type ErrStackFailed struct {
+	gophercloud.BaseError
+	StackName string
+	StackID   string
+	Status    string
+	Reason    string
+	// Events holds the most recent events of the stack, if they were
+	// retrieved.
+	Events []stackevents.Event
+}
+
+func (e ErrStackFailed) Error() string {
+	return fmt.Sprintf("Stack [%s/%s] is in status %s: %s", e.StackName, e.StackID, e.Status, e.Reason)
+}
+
+// ErrWaitTimeout is returned by the waiters in this package when a stack does
+// not reach the expected status in time.
+type ErrWaitTimeout struct {
+	gophercloud.BaseError
+	StackName  string
+	StackID    string
+	Status     string
+	LastStatus string
+}
+
+func (e ErrWaitTimeout) Error() string {
+	return fmt.Sprintf("Timed out waiting for stack [%s/%s] to reach %s, last status was %s", e.StackName, e.StackID, e.Status, e.LastStatus)
+}
+
+// ErrUnknownFields is returned by the ExtractStrict functions of this package
+// when Heat returned fields that are not modeled.
+type ErrUnknownFields struct {
+	gophercloud.BaseError
+	Type   string
+	Fields []string
+}
+
+func (e ErrUnknownFields) Error() string {
+	return fmt.Sprintf("Unknown fields in %s: %s", e.Type, strings.Join(e.Fields, ", "))
+}
+
+// ErrSnapshotFailed is returned by UpdateWithSnapshot when the snapshot taken
+// before the update fails.
+type ErrSnapshotFailed struct {
+	gophercloud.BaseError
+	SnapshotID string
+	Status     string
+	Reason     string
+}
+
+func (e ErrSnapshotFailed) Error() string {
+	return fmt.Sprintf("Snapshot [%s] is in status %s: %s", e.SnapshotID, e.Status, e.Reason)
+}
+
+// ErrTemplateURLUnreachable is returned by CheckTemplateURL when the template
+// URL cannot be fetched. Either Err or Status is set.
+type ErrTemplateURLUnreachable struct {
+	gophercloud.BaseError
+	URL    string
+	Status string
+	Err    error
+}
+
+func (e ErrTemplateURLUnreachable) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("Template URL %s is unreachable: %s", e.URL, e.Err)
+	}
+	return fmt.Sprintf("Template URL %s is unreachable: %s", e.URL, e.Status)
+}
+
+// stackLockedRe matches the reason of conflicts caused by another operation
+// holding the stack, e.g. "already has an action (UPDATE) in progress".
+var stackLockedRe = regexp.MustCompile(`(?i)in progress|locked`)
+
+// IsStackLocked reports whether err is a 409 Conflict returned because the
+// stack is locked by another operation, as opposed to any other conflict.
+// Callers may back off and retry the operation once the stack is released.
+func IsStackLocked(err error) bool {
+	var body []byte
+	switch e := err.(type) {
+	case ErrActionInProgress:
+		return true
+	case *ErrActionInProgress:
+		return true
+	case gophercloud.ErrDefault409:
+		body = e.Body
+	case *gophercloud.ErrDefault409:
+		body = e.Body
+	case gophercloud.ErrUnexpectedResponseCode:
+		if e.Actual != http.StatusConflict {
+			return false
+		}
+		body = e.Body
+	default:
+		return false
+	}
+
+	var fault heatFault
+	if json.Unmarshal(body, &fault) != nil {
+		return stackLockedRe.Match(body)
+	}
+	return fault.Error.Type == "ActionInProgress" || stackLockedRe.MatchString(fault.Error.Message)
+}
+
+// ErrBodyTooLarge is returned by the To*Map methods of this package when
+// CheckBodySize is enabled and the request body exceeds MaxBodySize.
+type ErrBodyTooLarge struct {
+	gophercloud.BaseError
+	Size  int
+	Limit int
+}
+
+func (e ErrBodyTooLarge) Error() string {
+	return fmt.Sprintf("Request body of %d bytes exceeds the limit of %d bytes; "+
+		"consider storing the files in a Swift container given as FilesContainer", e.Size, e.Limit)
+}
+
+// ErrEndpointNotSet is returned by the operations of this package when the
+// ServiceClient has no endpoint, which usually means it was not obtained from
+// a provider client or was misconfigured.
+type ErrEndpointNotSet struct {
+	gophercloud.BaseError
+}
+
+func (e ErrEndpointNotSet) Error() string {
+	return "The endpoint of the orchestration service client is not set"
+}
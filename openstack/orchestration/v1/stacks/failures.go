@@ -0,0 +1,49 @@
+package stacks
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackevents"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackresources"
+)
+
+// ResourceFailure describes a resource of a stack that reached a *_FAILED
+// status.
+type ResourceFailure struct {
+	ResourceName string
+	StatusReason string
+	Time         time.Time
+}
+
+// FailureReasons lists the events of a stack and returns the failures of its
+// resources, oldest first, so that the root cause of a failed stack is usually
+// the first one. Events of the stack itself are left out, as they only repeat
+// the failure of a resource.
+func FailureReasons(c *gophercloud.ServiceClient, stackName, stackID string) ([]ResourceFailure, error) {
+	events, err := stackevents.ExtractAll(c, stackName, stackID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := []ResourceFailure{}
+	for _, event := range events {
+		if !stackresources.IsResourceFailed(event.ResourceStatus) {
+			continue
+		}
+		if event.ResourceName == stackName || event.PhysicalResourceID == stackID {
+			continue
+		}
+		failures = append(failures, ResourceFailure{
+			ResourceName: event.ResourceName,
+			StatusReason: event.ResourceStatusReason,
+			Time:         event.Time,
+		})
+	}
+
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Time.Before(failures[j].Time)
+	})
+	return failures, nil
+}
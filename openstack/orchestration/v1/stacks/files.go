@@ -0,0 +1,44 @@
+package stacks
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// FilesFromDir walks the directory tree rooted at root and returns the
+// contents of every regular file it contains, keyed by its slash-separated
+// path relative to root, for use as CreateOpts.Files. Files that are not
+// valid UTF-8 are base64-encoded, as python-heatclient does, so templates
+// must decode them, e.g. with the "base64" encoding of a SoftwareConfig.
+func FilesFromDir(root string) (map[string]interface{}, error) {
+	files := make(map[string]interface{})
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if utf8.Valid(content) {
+			files[filepath.ToSlash(rel)] = string(content)
+		} else {
+			files[filepath.ToSlash(rel)] = base64.StdEncoding.EncodeToString(content)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
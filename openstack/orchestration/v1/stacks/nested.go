@@ -0,0 +1,62 @@
+package stacks
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackresources"
+)
+
+// NestedStackIDs returns every stack nested, at any depth, under the given
+// stack, e.g. to delete or tag a whole stack hierarchy. Nested stacks are
+// found through the "nested" link Heat sets on the resources backed by one,
+// such as OS::Heat::Stack, OS::Heat::AutoScalingGroup, OS::Heat::ResourceGroup
+// and provider template resources. The stacks are returned breadth first,
+// without the given stack, and each stack is visited once so that a cycle in
+// the links cannot loop forever.
+func NestedStackIDs(c *gophercloud.ServiceClient, stackName, stackID string) ([]StackRef, error) {
+	seen := map[string]bool{stackID: true}
+	queue := []StackRef{{Name: stackName, ID: stackID}}
+	refs := []StackRef{}
+
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+
+		resources, err := stackresources.ExtractAll(c, parent.Name, parent.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			ref, ok := nestedStackRef(resource.Links)
+			if !ok || seen[ref.ID] {
+				continue
+			}
+			seen[ref.ID] = true
+			refs = append(refs, ref)
+			queue = append(queue, ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// nestedStackRef returns the stack a "nested" link of a resource points to,
+// of the form .../stacks/{name}/{id}.
+func nestedStackRef(links []gophercloud.Link) (StackRef, bool) {
+	for _, link := range links {
+		if link.Rel != "nested" {
+			continue
+		}
+		u, err := url.Parse(link.Href)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if n := len(parts); n >= 3 && parts[n-3] == "stacks" {
+			return StackRef{Name: parts[n-2], ID: parts[n-1]}, true
+		}
+	}
+	return StackRef{}, false
+}
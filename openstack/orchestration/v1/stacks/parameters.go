@@ -0,0 +1,124 @@
+package stacks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stacktemplates"
+)
+
+// MergeParameters merges multiple parameter maps into a single map. Maps are
+// applied in order, so values in later maps override values in earlier ones.
+// Nil maps are skipped.
+func MergeParameters(maps ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// MergeRawParameters is the same as MergeParameters, but works on maps of
+// arbitrary values, such as the Parameters field of CreateOpts.
+func MergeRawParameters(maps ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// ParametersFromEnv collects the environment variables whose names start with
+// prefix into a parameter map. The prefix is stripped and the remainder is
+// converted to Heat's parameter naming convention by lower-casing it, so that
+// with a prefix of "STACK_", the variable STACK_KEY_NAME becomes the key_name
+// parameter. Variables that consist only of the prefix are ignored.
+func ParametersFromEnv(prefix string) map[string]string {
+	params := make(map[string]string)
+	for _, kv := range os.Environ() {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		name, value := kv[:i], kv[i+1:]
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		params[strings.ToLower(strings.TrimPrefix(name, prefix))] = value
+	}
+	return params
+}
+
+// ValidateParameters validates params against the parameter schema of
+// template before a stack is created. The schema is obtained by validating
+// the template with stacktemplates.Validate. Every required parameter (one
+// without a default) must be present, and every value must be compatible with
+// the declared type of its parameter. All problems found are returned together
+// in an ErrInvalidParameters.
+func ValidateParameters(c *gophercloud.ServiceClient, template []byte, params map[string]string) error {
+	var s struct {
+		Parameters map[string]map[string]interface{} `json:"Parameters"`
+	}
+	err := stacktemplates.Validate(c, stacktemplates.ValidateOpts{Template: string(template)}).ExtractInto(&s)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(s.Parameters))
+	for name := range s.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		schema := s.Parameters[name]
+		value, ok := params[name]
+		if !ok {
+			if _, hasDefault := schema["Default"]; !hasDefault {
+				problems = append(problems, fmt.Sprintf("missing required parameter [%s]", name))
+			}
+			continue
+		}
+		paramType, _ := schema["Type"].(string)
+		if !parameterTypeCompatible(paramType, value) {
+			problems = append(problems, fmt.Sprintf("parameter [%s] is not a valid %s: %q", name, paramType, value))
+		}
+	}
+
+	if len(problems) > 0 {
+		return ErrInvalidParameters{Problems: problems}
+	}
+	return nil
+}
+
+// parameterTypeCompatible reports whether value can be used for a parameter
+// of the given type. The type is matched case-insensitively, so that both the
+// types of validate schemas, e.g. "Number", and of templates, e.g. "number",
+// are understood.
+func parameterTypeCompatible(paramType, value string) bool {
+	switch strings.ToLower(paramType) {
+	case "number":
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case "boolean":
+		switch strings.ToLower(value) {
+		case "t", "true", "on", "y", "yes", "1", "f", "false", "off", "n", "no", "0":
+			return true
+		}
+		return false
+	case "json":
+		var v interface{}
+		return json.Unmarshal([]byte(value), &v) == nil
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package stacks
+
+import (
+	"os"
+	"testing"
+
+	th "github.com/gophercloud/gophercloud/testhelper"
+)
+
+func TestMergeParameters(t *testing.T) {
+	defaults := map[string]string{
+		"flavor": "m1.tiny",
+		"image":  "cirros",
+		"count":  "1",
+	}
+	env := map[string]string{
+		"flavor": "m1.small",
+	}
+	flags := map[string]string{
+		"flavor": "m1.large",
+		"count":  "3",
+	}
+
+	expected := map[string]string{
+		"flavor": "m1.large",
+		"image":  "cirros",
+		"count":  "3",
+	}
+	actual := MergeParameters(defaults, nil, env, flags)
+	th.AssertDeepEquals(t, expected, actual)
+
+	expected = map[string]string{
+		"flavor": "m1.small",
+		"image":  "cirros",
+		"count":  "3",
+	}
+	actual = MergeParameters(defaults, flags, env)
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestMergeParametersNil(t *testing.T) {
+	actual := MergeParameters(nil, nil)
+	th.AssertDeepEquals(t, map[string]string{}, actual)
+}
+
+func TestMergeRawParameters(t *testing.T) {
+	defaults := map[string]interface{}{
+		"flavor": "m1.tiny",
+		"count":  1,
+	}
+	flags := map[string]interface{}{
+		"count": 3,
+	}
+
+	expected := map[string]interface{}{
+		"flavor": "m1.tiny",
+		"count":  3,
+	}
+	actual := MergeRawParameters(nil, defaults, flags, nil)
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestParametersFromEnv(t *testing.T) {
+	os.Setenv("GOPHERCLOUD_TEST_PARAM_KEY_NAME", "heat_key")
+	os.Setenv("GOPHERCLOUD_TEST_PARAM_FLAVOR", "m1.tiny")
+	os.Setenv("GOPHERCLOUD_TEST_PARAM_", "ignored")
+	defer os.Unsetenv("GOPHERCLOUD_TEST_PARAM_KEY_NAME")
+	defer os.Unsetenv("GOPHERCLOUD_TEST_PARAM_FLAVOR")
+	defer os.Unsetenv("GOPHERCLOUD_TEST_PARAM_")
+
+	expected := map[string]string{
+		"key_name": "heat_key",
+		"flavor":   "m1.tiny",
+	}
+	actual := ParametersFromEnv("GOPHERCLOUD_TEST_PARAM_")
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestParameterTypeCompatible(t *testing.T) {
+	for _, paramType := range []string{"Json", "json", "JSON"} {
+		th.AssertEquals(t, true, parameterTypeCompatible(paramType, `{"a": 1}`))
+		th.AssertEquals(t, false, parameterTypeCompatible(paramType, `{`))
+	}
+	th.AssertEquals(t, false, parameterTypeCompatible("number", "ten"))
+	th.AssertEquals(t, false, parameterTypeCompatible("Boolean", "maybe"))
+	th.AssertEquals(t, true, parameterTypeCompatible("string", "anything"))
+}
@@ -1,12 +1,67 @@
 package stacks
 
 import (
+	"encoding/json"
+	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/pagination"
 )
 
+// MoreHeadersBuilder is an optional interface of the options of the operations
+// in this package. Options implementing it, such as CreateOpts, send the
+// returned headers along with the request.
+type MoreHeadersBuilder interface {
+	ToStackMoreHeaders() map[string]string
+}
+
+// defaultTimeout holds the duration set by SetDefaultTimeout.
+var defaultTimeout int64
+
+// SetDefaultTimeout bounds each request sent by the operations of this package
+// to d, unless the ServiceClient sets its own RequestTimeout. A request taking
+// longer fails with a deadline error. Zero, the default, removes the bound.
+//
+// The timeout applies to every single request, including each poll of the
+// waiters: the context given to WaitForStatusContext bounds the whole wait
+// while the default timeout bounds each of its requests. Listing stacks goes
+// through a pager and is only bounded by the ServiceClient's RequestTimeout.
+func SetDefaultTimeout(d time.Duration) {
+	atomic.StoreInt64(&defaultTimeout, int64(d))
+}
+
+// requestTimeout returns the timeout of the requests sent with c.
+func requestTimeout(c *gophercloud.ServiceClient) time.Duration {
+	if c.RequestTimeout > 0 {
+		return c.RequestTimeout
+	}
+	return time.Duration(atomic.LoadInt64(&defaultTimeout))
+}
+
+// checkEndpoint returns an ErrEndpointNotSet if c has no endpoint to build the
+// URLs of requests from.
+func checkEndpoint(c *gophercloud.ServiceClient) error {
+	if c.ResourceBaseURL() == "" {
+		return ErrEndpointNotSet{}
+	}
+	return nil
+}
+
+// moreHeaders returns a copy of the extra headers of opts, if it has any. The
+// ServiceClient adds its own headers to those of a request, which must not
+// end up in the map of the caller.
+func moreHeaders(opts interface{}) map[string]string {
+	if b, ok := opts.(MoreHeadersBuilder); ok {
+		return copyStringMap(b.ToStackMoreHeaders())
+	}
+	return nil
+}
+
 // CreateOptsBuilder is the interface options structs have to satisfy in order
 // to be used in the main Create operation in this package. Since many
 // extensions decorate or modify the common logic, it is useful for them to
@@ -22,77 +77,154 @@ type CreateOpts struct {
 	Name string `json:"stack_name" required:"true"`
 	// A structure that contains either the template file or url. Call the
 	// associated methods to extract the information relevant to send in a create request.
-	TemplateOpts *Template `json:"-" required:"true"`
+	TemplateOpts *Template `json:"-" required:"true" stack:"template"`
 	// Enables or disables deletion of all stack resources when a stack
 	// creation fails. Default is true, meaning all resources are not deleted when
 	// stack creation fails.
 	DisableRollback *bool `json:"disable_rollback,omitempty"`
 	// A structure that contains details for the environment of the stack.
-	EnvironmentOpts *Environment `json:"-"`
+	EnvironmentOpts *Environment `json:"-" stack:"environment"`
 	// User-defined parameters to pass to the template.
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 	// The timeout for stack creation in minutes.
 	Timeout int `json:"timeout_mins,omitempty"`
 	// A list of tags to assosciate with the Stack
-	Tags []string `json:"-"`
+	Tags []string `json:"-" stack:"tags"`
+	// Headers to send when fetching a template given by URL, and the files it
+	// references, e.g. to authenticate against the server hosting them.
+	// Templates given by URL are always fetched by gophercloud and inlined in
+	// the request, so Heat never has to fetch them itself.
+	TemplateURLHeaders map[string]string `json:"-"`
+	// The name of a Swift container Heat should fetch the files referenced by
+	// the template and environment from. It may be combined with the files
+	// inlined from TemplateOpts and EnvironmentOpts, in which case both are
+	// sent.
+	FilesContainer string `json:"files_container,omitempty"`
+	// Files to send along with the template, keyed by the name the template
+	// refers to them with, e.g. as built by FilesFromDir. Values must be
+	// strings or byte slices. They take precedence over the files inlined
+	// from TemplateOpts and EnvironmentOpts.
+	Files map[string]interface{} `json:"-" stack:"files"`
+	// Extra headers to send with the request, e.g. correlation IDs required by
+	// a gateway. They take precedence over the MoreHeaders of the client.
+	MoreHeaders map[string]string `json:"-"`
+	// ExtraFields are added to the request body, e.g. for parameters of newer
+	// Heat versions that CreateOpts does not know yet. Fields set by the
+	// other options take precedence: an extra field of the same name is
+	// ignored.
+	ExtraFields map[string]interface{} `json:"-" stack:"extra"`
+	// Compress sends the request body gzip-compressed, which speeds up
+	// creating stacks from very large templates where Heat, or the gateway in
+	// front of it, accepts `Content-Encoding: gzip`. If the server rejects the
+	// encoding with a 415, the request is sent again uncompressed.
+	Compress bool `json:"-"`
+	// RawBody, if set, is sent verbatim as the request body. All the other
+	// fields are then ignored and no validation takes place, which is meant
+	// for clients tracking the Heat API schema on their own.
+	RawBody map[string]interface{} `json:"-"`
+}
+
+// ToStackMoreHeaders returns the extra headers to send with the request.
+func (opts CreateOpts) ToStackMoreHeaders() map[string]string {
+	return opts.MoreHeaders
 }
 
 // ToStackCreateMap casts a CreateOpts struct to a map.
 func (opts CreateOpts) ToStackCreateMap() (map[string]interface{}, error) {
-	b, err := gophercloud.BuildRequestBody(opts, "")
-	if err != nil {
-		return nil, err
+	if opts.RawBody != nil {
+		return opts.RawBody, nil
+	}
+	if len(opts.TemplateURLHeaders) > 0 && opts.TemplateOpts != nil && opts.TemplateOpts.client == nil {
+		opts.TemplateOpts.client = headerClient{client: newHTTPClient(), headers: opts.TemplateURLHeaders}
 	}
+	return buildStackMap(opts)
+}
 
-	if err := opts.TemplateOpts.Parse(); err != nil {
-		return nil, err
+// CheckTemplateURL checks that the URL of TemplateOpts can be fetched, sending
+// TemplateURLHeaders along, so that a wrong URL is reported before the stack
+// is created. A HEAD request is tried first, then a GET if the server doesn't
+// allow HEAD. If client is nil, the client used to fetch templates is used.
+// It returns an ErrTemplateURLUnreachable on failure, and nil if TemplateOpts
+// has no URL. The opts are left untouched.
+func (opts CreateOpts) CheckTemplateURL(client *http.Client) error {
+	if opts.TemplateOpts == nil || opts.TemplateOpts.URL == "" {
+		return nil
 	}
 
-	if err := opts.TemplateOpts.getFileContents(opts.TemplateOpts.Parsed, ignoreIfTemplate, true); err != nil {
-		return nil, err
+	baseURL := opts.TemplateOpts.baseURL
+	if baseURL == "" {
+		u, err := getBasePath()
+		if err != nil {
+			return err
+		}
+		baseURL = u
+	}
+	url, err := gophercloud.NormalizePathURL(baseURL, opts.TemplateOpts.URL)
+	if err != nil {
+		return ErrTemplateURLUnreachable{URL: opts.TemplateOpts.URL, Err: err}
 	}
-	opts.TemplateOpts.fixFileRefs()
-	b["template"] = string(opts.TemplateOpts.Bin)
 
-	files := make(map[string]string)
-	for k, v := range opts.TemplateOpts.Files {
-		files[k] = v
+	if client == nil {
+		client = newHTTPClient()
 	}
 
-	if opts.EnvironmentOpts != nil {
-		if err := opts.EnvironmentOpts.Parse(); err != nil {
+	do := func(method string) (*http.Response, error) {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
 			return nil, err
 		}
-		if err := opts.EnvironmentOpts.getRRFileContents(ignoreIfEnvironment); err != nil {
-			return nil, err
+		for k, v := range opts.TemplateURLHeaders {
+			req.Header.Set(k, v)
 		}
-		opts.EnvironmentOpts.fixFileRefs()
-		for k, v := range opts.EnvironmentOpts.Files {
-			files[k] = v
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
 		}
-		b["environment"] = string(opts.EnvironmentOpts.Bin)
+		resp.Body.Close()
+		return resp, nil
 	}
 
-	if len(files) > 0 {
-		b["files"] = files
+	resp, err := do("HEAD")
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		resp, err = do("GET")
 	}
-
-	if opts.Tags != nil {
-		b["tags"] = strings.Join(opts.Tags, ",")
+	if err != nil {
+		return ErrTemplateURLUnreachable{URL: url, Err: err}
 	}
-
-	return b, nil
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return ErrTemplateURLUnreachable{URL: url, Status: resp.Status}
+	}
+	return nil
 }
 
 // Create accepts a CreateOpts struct and creates a new stack using the values
 // provided.
 func Create(c *gophercloud.ServiceClient, opts CreateOptsBuilder) (r CreateResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
 	b, err := opts.ToStackCreateMap()
 	if err != nil {
 		r.Err = err
 		return
 	}
-	_, r.Err = c.Post(createURL(c), b, &r.Body, nil)
+	return create(c, opts, b)
+}
+
+// create sends b, the request body built from opts, as a Create request.
+// Building the body inlines the files referenced by the template and
+// environment of opts, which must only be done once.
+func create(c *gophercloud.ServiceClient, opts CreateOptsBuilder, b map[string]interface{}) (r CreateResult) {
+	resp, err := sendMaybeCompressed(c.Post, createURL(c), b, &r.Body, &gophercloud.RequestOpts{
+		Timeout:     requestTimeout(c),
+		MoreHeaders: moreHeaders(opts),
+	}, compresses(opts))
+	r.Err = err
+	if resp != nil {
+		r.Header = resp.Header
+	}
 	return
 }
 
@@ -114,7 +246,7 @@ type AdoptOpts struct {
 	Name string `json:"stack_name" required:"true"`
 	// A structure that contains either the template file or url. Call the
 	// associated methods to extract the information relevant to send in a create request.
-	TemplateOpts *Template `json:"-" required:"true"`
+	TemplateOpts *Template `json:"-" required:"true" stack:"template"`
 	// The timeout for stack creation in minutes.
 	Timeout int `json:"timeout_mins,omitempty"`
 	// A structure that contains either the template file or url. Call the
@@ -125,63 +257,46 @@ type AdoptOpts struct {
 	// stack creation fails.
 	DisableRollback *bool `json:"disable_rollback,omitempty"`
 	// A structure that contains details for the environment of the stack.
-	EnvironmentOpts *Environment `json:"-"`
+	EnvironmentOpts *Environment `json:"-" stack:"environment"`
 	// User-defined parameters to pass to the template.
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Extra headers to send with the request, e.g. correlation IDs required by
+	// a gateway. They take precedence over the MoreHeaders of the client.
+	MoreHeaders map[string]string `json:"-"`
+}
+
+// ToStackMoreHeaders returns the extra headers to send with the request.
+func (opts AdoptOpts) ToStackMoreHeaders() map[string]string {
+	return opts.MoreHeaders
 }
 
 // ToStackAdoptMap casts a CreateOpts struct to a map.
 func (opts AdoptOpts) ToStackAdoptMap() (map[string]interface{}, error) {
-	b, err := gophercloud.BuildRequestBody(opts, "")
-	if err != nil {
-		return nil, err
-	}
-
-	if err := opts.TemplateOpts.Parse(); err != nil {
-		return nil, err
-	}
-
-	if err := opts.TemplateOpts.getFileContents(opts.TemplateOpts.Parsed, ignoreIfTemplate, true); err != nil {
-		return nil, err
-	}
-	opts.TemplateOpts.fixFileRefs()
-	b["template"] = string(opts.TemplateOpts.Bin)
-
-	files := make(map[string]string)
-	for k, v := range opts.TemplateOpts.Files {
-		files[k] = v
-	}
-
-	if opts.EnvironmentOpts != nil {
-		if err := opts.EnvironmentOpts.Parse(); err != nil {
-			return nil, err
-		}
-		if err := opts.EnvironmentOpts.getRRFileContents(ignoreIfEnvironment); err != nil {
-			return nil, err
-		}
-		opts.EnvironmentOpts.fixFileRefs()
-		for k, v := range opts.EnvironmentOpts.Files {
-			files[k] = v
-		}
-		b["environment"] = string(opts.EnvironmentOpts.Bin)
-	}
-
-	if len(files) > 0 {
-		b["files"] = files
-	}
-
-	return b, nil
+	return buildStackMap(opts)
 }
 
 // Adopt accepts an AdoptOpts struct and creates a new stack using the resources
 // from another stack.
 func Adopt(c *gophercloud.ServiceClient, opts AdoptOptsBuilder) (r AdoptResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
 	b, err := opts.ToStackAdoptMap()
 	if err != nil {
 		r.Err = err
 		return
 	}
-	_, r.Err = c.Post(adoptURL(c), b, &r.Body, nil)
+	return adopt(c, opts, b)
+}
+
+// adopt sends b, the request body built from opts, as an Adopt request.
+func adopt(c *gophercloud.ServiceClient, opts AdoptOptsBuilder, b map[string]interface{}) (r AdoptResult) {
+	_, r.Err = c.Post(adoptURL(c), b, &r.Body, &gophercloud.RequestOpts{
+		Timeout:     requestTimeout(c),
+		MoreHeaders: moreHeaders(opts),
+	})
 	return
 }
 
@@ -218,12 +333,15 @@ type ListOptsBuilder interface {
 // by a particular network attribute. SortDir sets the direction, and is either
 // `asc' or `desc'. Marker and Limit are used for pagination.
 type ListOpts struct {
-	Status  string  `q:"status"`
-	Name    string  `q:"name"`
-	Marker  string  `q:"marker"`
-	Limit   int     `q:"limit"`
-	SortKey SortKey `q:"sort_keys"`
-	SortDir SortDir `q:"sort_dir"`
+	Status string `q:"status"`
+	// Statuses lists stacks in any of the given statuses. It may be combined
+	// with Status, in which case stacks in any of them are listed.
+	Statuses []string `q:"status"`
+	Name     string   `q:"name"`
+	Marker   string   `q:"marker"`
+	Limit    int      `q:"limit"`
+	SortKey  SortKey  `q:"sort_keys"`
+	SortDir  SortDir  `q:"sort_dir"`
 }
 
 // ToStackListQuery formats a ListOpts into a query string.
@@ -239,6 +357,10 @@ func (opts ListOpts) ToStackListQuery() (string, error) {
 // stacks. It accepts a ListOpts struct, which allows you to filter and sort
 // the returned collection for greater efficiency.
 func List(c *gophercloud.ServiceClient, opts ListOptsBuilder) pagination.Pager {
+	if err := checkEndpoint(c); err != nil {
+		return pagination.Pager{Err: err}
+	}
+
 	url := listURL(c)
 	if opts != nil {
 		query, err := opts.ToStackListQuery()
@@ -253,12 +375,258 @@ func List(c *gophercloud.ServiceClient, opts ListOptsBuilder) pagination.Pager {
 	return pagination.NewPager(c, url, createPage)
 }
 
+// ListDetail is the same as List, but requests the detailed representation of
+// each stack, which includes parameters, outputs and capabilities. This avoids
+// a Get for every stack at the cost of a considerably larger response; prefer
+// List when only summary information is needed.
+func ListDetail(c *gophercloud.ServiceClient, opts ListOptsBuilder) pagination.Pager {
+	if err := checkEndpoint(c); err != nil {
+		return pagination.Pager{Err: err}
+	}
+
+	url := listDetailURL(c)
+	if opts != nil {
+		query, err := opts.ToStackListQuery()
+		if err != nil {
+			return pagination.Pager{Err: err}
+		}
+		url += query
+	}
+	createPage := func(r pagination.PageResult) pagination.Page {
+		return StackDetailPage{pagination.SinglePageBase(r)}
+	}
+	return pagination.NewPager(c, url, createPage)
+}
+
+// ExtractAllDetail returns the detailed representation of every stack
+// matching opts, as listed by ListDetail. Some deployments only support the
+// summary listing of List; if fallback is true and the detailed listing is
+// rejected with a 400 or 404, the stacks are listed with List instead and
+// retrieved one by one with Get. As that costs one request per stack, the
+// fallback has to be asked for: if fallback is false the error is returned.
+func ExtractAllDetail(c *gophercloud.ServiceClient, opts ListOptsBuilder, fallback bool) ([]RetrievedStack, error) {
+	page, err := ListDetail(c, opts).AllPages()
+	if err == nil {
+		return ExtractStacksDetail(page)
+	}
+	switch err.(type) {
+	case gophercloud.ErrDefault400, gophercloud.ErrDefault404:
+		if !fallback {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	page, err = List(c, opts).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	listed, err := ExtractStacks(page)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]StackRef, 0, len(listed))
+	for _, stack := range listed {
+		refs = append(refs, StackRef{Name: stack.Name, ID: stack.ID})
+	}
+	results := GetAll(c, refs, 1)
+
+	detailed := make([]RetrievedStack, 0, len(refs))
+	for _, ref := range refs {
+		stack, err := results[ref].Extract()
+		if err != nil {
+			return nil, err
+		}
+		detailed = append(detailed, *stack)
+	}
+	return detailed, nil
+}
+
+// listUpdatedSincePageSize is the number of stacks requested per page by
+// ListUpdatedSince.
+const listUpdatedSincePageSize = 100
+
+// ListUpdatedSince returns the stacks updated at or after since, most recently
+// updated first. A stack that was never updated is judged by its creation
+// time. Heat cannot filter stacks by time, so every stack is listed, page by
+// page, and the stacks are filtered on the client.
+func ListUpdatedSince(c *gophercloud.ServiceClient, since time.Time) ([]ListedStack, error) {
+	if err := checkEndpoint(c); err != nil {
+		return nil, err
+	}
+
+	query, err := ListOpts{Limit: listUpdatedSincePageSize}.ToStackListQuery()
+	if err != nil {
+		return nil, err
+	}
+	pager := pagination.NewPager(c, listURL(c)+query, func(r pagination.PageResult) pagination.Page {
+		p := stackMarkerPage{MarkerPageBase: pagination.MarkerPageBase{PageResult: r}}
+		p.MarkerPageBase.Owner = p
+		return p
+	})
+
+	updated := []ListedStack{}
+	err = pager.EachPage(func(page pagination.Page) (bool, error) {
+		stacks, err := page.(stackMarkerPage).extract()
+		if err != nil {
+			return false, err
+		}
+		for _, stack := range stacks {
+			if !lastChanged(stack).Before(since) {
+				updated = append(updated, stack)
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(updated, func(i, j int) bool {
+		return lastChanged(updated[i]).After(lastChanged(updated[j]))
+	})
+	return updated, nil
+}
+
+// lastChanged returns the update time of stack, or its creation time if it
+// was never updated.
+func lastChanged(stack ListedStack) time.Time {
+	if stack.UpdatedTime.IsZero() {
+		return stack.CreationTime
+	}
+	return stack.UpdatedTime
+}
+
 // Get retreives a stack based on the stack name and stack ID.
 func Get(c *gophercloud.ServiceClient, stackName, stackID string) (r GetResult) {
-	_, r.Err = c.Get(getURL(c, stackName, stackID), &r.Body, nil)
+	getNumbers(c, getURL(c, stackName, stackID), nil, &r.Result)
+	return
+}
+
+// GetForTenant retrieves a stack of the tenant tenantID rather than the one
+// the client is scoped to. It requires the client's endpoint to end with the
+// tenant ID, as Heat endpoints do, and the caller to be allowed to operate
+// across projects.
+func GetForTenant(c *gophercloud.ServiceClient, tenantID, stackName, stackID string) (r GetResult) {
+	getNumbers(c, getForTenantURL(c, tenantID, stackName, stackID), nil, &r.Result)
+	return
+}
+
+// getNumbers issues a GET request and decodes the response into r.Body,
+// keeping numbers as json.Number so that large integers, e.g. 64-bit IDs
+// given as parameters or outputs, don't lose precision.
+func getNumbers(c *gophercloud.ServiceClient, url string, headers map[string]string, r *gophercloud.Result) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	resp, err := c.Get(url, nil, &gophercloud.RequestOpts{
+		Timeout:     requestTimeout(c),
+		MoreHeaders: headers,
+	})
+	if err != nil {
+		r.Err = err
+		return
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	r.Err = decoder.Decode(&r.Body)
+}
+
+// GetOptsBuilder allows extensions to add additional parameters to the
+// GetWithOpts request.
+type GetOptsBuilder interface {
+	ToStackGetQuery() (string, error)
+}
+
+// GetOpts allows the response of a GetWithOpts request to be tailored.
+type GetOpts struct {
+	// WithResolvedParameters asks Heat to resolve values such as outputs
+	// where the caller is permitted to see them. Parameters declared with
+	// `hidden: true` may still be returned masked (for example "******")
+	// regardless of this setting; masked values are passed through as-is.
+	WithResolvedParameters *bool `q:"resolve_outputs"`
+	// Extra headers to send with the request, e.g. correlation IDs required by
+	// a gateway. They take precedence over the MoreHeaders of the client.
+	MoreHeaders map[string]string `json:"-"`
+}
+
+// ToStackMoreHeaders returns the extra headers to send with the request.
+func (opts GetOpts) ToStackMoreHeaders() map[string]string {
+	return opts.MoreHeaders
+}
+
+// ToStackGetQuery formats a GetOpts into a query string.
+func (opts GetOpts) ToStackGetQuery() (string, error) {
+	q, err := gophercloud.BuildQueryString(opts)
+	return q.String(), err
+}
+
+// GetWithOpts retreives a stack based on the stack name and stack ID, passing
+// the given options as query parameters.
+func GetWithOpts(c *gophercloud.ServiceClient, stackName, stackID string, opts GetOptsBuilder) (r GetResult) {
+	url := getURL(c, stackName, stackID)
+	if opts != nil {
+		query, err := opts.ToStackGetQuery()
+		if err != nil {
+			r.Err = err
+			return
+		}
+		url += query
+	}
+	getNumbers(c, url, moreHeaders(opts), &r.Result)
 	return
 }
 
+// StackRef identifies a stack by its name and ID.
+type StackRef struct {
+	Name string
+	ID   string
+}
+
+// String returns the ref as "name/id", the form used in stack URLs.
+func (r StackRef) String() string {
+	return r.Name + "/" + r.ID
+}
+
+// GetAll retrieves the given stacks concurrently, running at most concurrency
+// Get requests at a time; a concurrency of less than 1 fetches the stacks one
+// after the other. The result of every Get, including its error, is returned
+// keyed by ref: a stack that cannot be found only fails its own entry.
+func GetAll(c *gophercloud.ServiceClient, refs []StackRef, concurrency int) map[StackRef]GetResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[StackRef]GetResult, len(refs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ref StackRef) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			r := Get(c, ref.Name, ref.ID)
+			mu.Lock()
+			results[ref] = r
+			mu.Unlock()
+		}(ref)
+	}
+	wg.Wait()
+
+	return results
+}
+
 // UpdateOptsBuilder is the interface options structs have to satisfy in order
 // to be used in the Update operation in this package.
 type UpdateOptsBuilder interface {
@@ -276,21 +644,49 @@ type UpdatePatchOptsBuilder interface {
 type UpdateOpts struct {
 	// A structure that contains either the template file or url. Call the
 	// associated methods to extract the information relevant to send in a create request.
-	TemplateOpts *Template `json:"-"`
+	TemplateOpts *Template `json:"-" stack:"template"`
 	// A structure that contains details for the environment of the stack.
-	EnvironmentOpts *Environment `json:"-"`
+	EnvironmentOpts *Environment `json:"-" stack:"environment"`
 	// User-defined parameters to pass to the template.
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 	// The timeout for stack creation in minutes.
 	Timeout int `json:"timeout_mins,omitempty"`
 	// A list of tags to associate with the Stack
-	Tags []string `json:"-"`
+	Tags []string `json:"-" stack:"tags"`
+	// PreserveTags makes Update fetch the current tags of the stack and send
+	// them again when Tags is empty, so that the update does not clear them.
+	// UpdatePatch keeps the current tags anyway.
+	PreserveTags bool `json:"-"`
+	// Existing makes Heat reuse the current template, environment and
+	// parameters of the stack for anything not given in the update, so that
+	// e.g. only the tags of a stack can be changed. A template is not
+	// required when Existing is set.
+	Existing bool `json:"existing,omitempty"`
+	// The name of a Swift container Heat should fetch the files referenced by
+	// the template and environment from.
+	FilesContainer string `json:"files_container,omitempty"`
+	// Extra headers to send with the request, e.g. correlation IDs required by
+	// a gateway. They take precedence over the MoreHeaders of the client.
+	MoreHeaders map[string]string `json:"-"`
+	// ExtraFields are added to the request body, e.g. for parameters of newer
+	// Heat versions that UpdateOpts does not know yet. Fields set by the
+	// other options take precedence: an extra field of the same name is
+	// ignored.
+	ExtraFields map[string]interface{} `json:"-" stack:"extra"`
+	// Compress sends the request body of Update and UpdatePatch
+	// gzip-compressed, as for CreateOpts.
+	Compress bool `json:"-"`
 }
 
-// ToStackUpdateMap validates that a template was supplied and calls
-// the toStackUpdateMap private function.
+// ToStackMoreHeaders returns the extra headers to send with the request.
+func (opts UpdateOpts) ToStackMoreHeaders() map[string]string {
+	return opts.MoreHeaders
+}
+
+// ToStackUpdateMap validates that a template was supplied, unless Existing is
+// set, and calls the toStackUpdateMap private function.
 func (opts UpdateOpts) ToStackUpdateMap() (map[string]interface{}, error) {
-	if opts.TemplateOpts == nil {
+	if opts.TemplateOpts == nil && !opts.Existing {
 		return nil, ErrTemplateRequired{}
 	}
 	return toStackUpdateMap(opts)
@@ -302,83 +698,274 @@ func (opts UpdateOpts) ToStackUpdatePatchMap() (map[string]interface{}, error) {
 	return toStackUpdateMap(opts)
 }
 
-// ToStackUpdateMap casts a CreateOpts struct to a map.
-func toStackUpdateMap(opts UpdateOpts) (map[string]interface{}, error) {
-	b, err := gophercloud.BuildRequestBody(opts, "")
-	if err != nil {
-		return nil, err
+// preservesTags reports whether the current tags of a stack have to be sent
+// along with an update built from opts.
+func preservesTags(opts UpdateOptsBuilder) bool {
+	switch o := opts.(type) {
+	case UpdateOpts:
+		return o.PreserveTags && len(o.Tags) == 0
+	case *UpdateOpts:
+		return o.PreserveTags && len(o.Tags) == 0
 	}
+	return false
+}
 
-	files := make(map[string]string)
-
-	if opts.TemplateOpts != nil {
-		if err := opts.TemplateOpts.Parse(); err != nil {
-			return nil, err
-		}
-
-		if err := opts.TemplateOpts.getFileContents(opts.TemplateOpts.Parsed, ignoreIfTemplate, true); err != nil {
-			return nil, err
-		}
-		opts.TemplateOpts.fixFileRefs()
-		b["template"] = string(opts.TemplateOpts.Bin)
+// ToStackUpdateMap casts a CreateOpts struct to a map.
+func toStackUpdateMap(opts UpdateOpts) (map[string]interface{}, error) {
+	return buildStackMap(opts)
+}
 
-		for k, v := range opts.TemplateOpts.Files {
-			files[k] = v
-		}
+// Update accepts an UpdateOpts struct and updates an existing stack using the
+//  http PUT verb with the values provided. opts.TemplateOpts is required.
+func Update(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdateOptsBuilder) (r UpdateResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
 	}
 
-	if opts.EnvironmentOpts != nil {
-		if err := opts.EnvironmentOpts.Parse(); err != nil {
-			return nil, err
-		}
-		if err := opts.EnvironmentOpts.getRRFileContents(ignoreIfEnvironment); err != nil {
-			return nil, err
+	b, err := opts.ToStackUpdateMap()
+	if err != nil {
+		r.Err = err
+		return
+	}
+	if preservesTags(opts) {
+		stack, err := Get(c, stackName, stackID).Extract()
+		if err != nil {
+			r.Err = err
+			return
 		}
-		opts.EnvironmentOpts.fixFileRefs()
-		for k, v := range opts.EnvironmentOpts.Files {
-			files[k] = v
+		if len(stack.Tags) > 0 {
+			b["tags"] = strings.Join(stack.Tags, ",")
 		}
-		b["environment"] = string(opts.EnvironmentOpts.Bin)
 	}
+	_, r.Err = sendMaybeCompressed(c.Put, updateURL(c, stackName, stackID), b, nil, &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
+		MoreHeaders:  moreHeaders(opts),
+		ErrorContext: stackErrorContext{},
+	}, compresses(opts))
+	return
+}
 
-	if len(files) > 0 {
-		b["files"] = files
+// RollbackToPrevious updates a stack back to the template it had before its
+// last update. This is only possible on Heat deployments that retain the
+// previous template and return it as `previous_template` when getting the
+// stack; otherwise ErrPreviousTemplateUnavailable is returned and the stack
+// is left untouched.
+func RollbackToPrevious(c *gophercloud.ServiceClient, stackName, stackID string) (UpdateResult, error) {
+	stack, err := Get(c, stackName, stackID).Extract()
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	if len(stack.PreviousTemplate) == 0 {
+		return UpdateResult{}, ErrPreviousTemplateUnavailable{StackName: stackName, StackID: stackID}
 	}
 
-	if opts.Tags != nil {
-		b["tags"] = strings.Join(opts.Tags, ",")
+	b, err := json.Marshal(stack.PreviousTemplate)
+	if err != nil {
+		return UpdateResult{}, err
 	}
+	template := new(Template)
+	template.Bin = b
 
-	return b, nil
+	return Update(c, stackName, stackID, UpdateOpts{TemplateOpts: template}), nil
 }
 
 // Update accepts an UpdateOpts struct and updates an existing stack using the
-//  http PUT verb with the values provided. opts.TemplateOpts is required.
-func Update(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdateOptsBuilder) (r UpdateResult) {
-	b, err := opts.ToStackUpdateMap()
+//  http PATCH verb with the values provided. opts.TemplateOpts is not required.
+func UpdatePatch(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdatePatchOptsBuilder) (r UpdateResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	b, err := opts.ToStackUpdatePatchMap()
 	if err != nil {
 		r.Err = err
 		return
 	}
-	_, r.Err = c.Put(updateURL(c, stackName, stackID), b, nil, nil)
+	_, r.Err = sendMaybeCompressed(c.Patch, updateURL(c, stackName, stackID), b, nil, &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
+		MoreHeaders:  moreHeaders(opts),
+		ErrorContext: stackErrorContext{},
+	}, compresses(opts))
 	return
 }
 
-// Update accepts an UpdateOpts struct and updates an existing stack using the
-//  http PATCH verb with the values provided. opts.TemplateOpts is not required.
-func UpdatePatch(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdatePatchOptsBuilder) (r UpdateResult) {
-	b, err := opts.ToStackUpdatePatchMap()
+// UpdatePreview previews the changes an Update with the given options would
+// make to the resources of a stack, without updating it.
+func UpdatePreview(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdateOptsBuilder) (r UpdatePreviewResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	b, err := opts.ToStackUpdateMap()
 	if err != nil {
 		r.Err = err
 		return
 	}
-	_, r.Err = c.Patch(updateURL(c, stackName, stackID), b, nil, nil)
+	_, r.Err = c.Put(updatePreviewURL(c, stackName, stackID), b, &r.Body, &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
+		OkCodes:      []int{200},
+		MoreHeaders:  moreHeaders(opts),
+		ErrorContext: stackErrorContext{},
+	})
 	return
 }
 
+// PredictReplacements previews an Update with the given options and returns
+// the logical names of the resources it would replace rather than update in
+// place. An empty slice means that the update is entirely in-place.
+func PredictReplacements(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdateOptsBuilder) ([]string, error) {
+	changes, err := UpdatePreview(c, stackName, stackID, opts).Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := make([]string, 0, len(changes.Replaced))
+	for _, resource := range changes.Replaced {
+		replaced = append(replaced, resource.Name)
+	}
+	return replaced, nil
+}
+
 // Delete deletes a stack based on the stack name and stack ID.
 func Delete(c *gophercloud.ServiceClient, stackName, stackID string) (r DeleteResult) {
-	_, r.Err = c.Delete(deleteURL(c, stackName, stackID), nil)
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	_, r.Err = c.Delete(deleteURL(c, stackName, stackID), &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
+		ErrorContext: stackErrorContext{},
+	})
+	return
+}
+
+// DeleteIfExists deletes a stack based on the stack name and stack ID like
+// Delete, but treats a stack that no longer exists as successfully deleted.
+func DeleteIfExists(c *gophercloud.ServiceClient, stackName, stackID string) (r DeleteResult) {
+	r = Delete(c, stackName, stackID)
+	if _, ok := r.Err.(gophercloud.ErrDefault404); ok {
+		r.Err = nil
+	}
+	return
+}
+
+// DeleteBatch deletes the given stacks concurrently, running at most
+// concurrency Delete requests at a time; a concurrency of less than 1 deletes
+// the stacks one after the other. The error of every Delete is returned keyed
+// by the ref's String form, with nil for stacks that were deleted. A stack
+// that no longer exists is treated as deleted.
+func DeleteBatch(c *gophercloud.ServiceClient, refs []StackRef, concurrency int) map[string]error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[string]error, len(refs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ref StackRef) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := DeleteIfExists(c, ref.Name, ref.ID).ExtractErr()
+			mu.Lock()
+			results[ref.String()] = err
+			mu.Unlock()
+		}(ref)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// doAction performs the given action on a stack.
+func doAction(c *gophercloud.ServiceClient, stackName, stackID string, action map[string]interface{}) (r ActionResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	_, r.Err = c.Post(actionURL(c, stackName, stackID), action, nil, &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
+		OkCodes:      []int{200, 202},
+		ErrorContext: stackErrorContext{},
+	})
+	return
+}
+
+// CancelUpdate cancels an in-progress update of a stack and rolls back the
+// changes made so far.
+func CancelUpdate(c *gophercloud.ServiceClient, stackName, stackID string) (r ActionResult) {
+	return doAction(c, stackName, stackID, map[string]interface{}{"cancel_update": nil})
+}
+
+// CancelUpdateNoRollback cancels an in-progress update of a stack without
+// rolling back the changes made so far.
+//
+// This is dangerous: the stack is left in a partially updated state, with some
+// resources updated and others not, and must be recovered by a further update.
+func CancelUpdateNoRollback(c *gophercloud.ServiceClient, stackName, stackID string) (r ActionResult) {
+	return doAction(c, stackName, stackID, map[string]interface{}{"cancel_without_rollback": nil})
+}
+
+// SnapshotOptsBuilder is the interface options structs have to satisfy in
+// order to be used in the Snapshot operation in this package.
+type SnapshotOptsBuilder interface {
+	ToStackSnapshotMap() (map[string]interface{}, error)
+}
+
+// SnapshotOpts is the common options struct used in this package's Snapshot
+// operation.
+type SnapshotOpts struct {
+	// The name of the snapshot.
+	Name string `json:"name,omitempty"`
+}
+
+// ToStackSnapshotMap casts a SnapshotOpts struct to a map.
+func (opts SnapshotOpts) ToStackSnapshotMap() (map[string]interface{}, error) {
+	return gophercloud.BuildRequestBody(opts, "")
+}
+
+// Snapshot takes a snapshot of a stack. The snapshot is created
+// asynchronously; use GetSnapshot to follow its status.
+func Snapshot(c *gophercloud.ServiceClient, stackName, stackID string, opts SnapshotOptsBuilder) (r SnapshotResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	b, err := opts.ToStackSnapshotMap()
+	if err != nil {
+		r.Err = err
+		return
+	}
+	_, r.Err = c.Post(snapshotsURL(c, stackName, stackID), b, &r.Body, &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
+		OkCodes:      []int{200},
+		ErrorContext: stackErrorContext{},
+	})
+	return
+}
+
+// GetSnapshot retrieves a snapshot of a stack.
+func GetSnapshot(c *gophercloud.ServiceClient, stackName, stackID, snapshotID string) (r GetSnapshotResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
+	_, r.Err = c.Get(snapshotURL(c, stackName, stackID, snapshotID), &r.Body, &gophercloud.RequestOpts{
+		Timeout: requestTimeout(c),
+	})
 	return
 }
 
@@ -397,70 +984,77 @@ type PreviewOpts struct {
 	Timeout int `json:"timeout_mins" required:"true"`
 	// A structure that contains either the template file or url. Call the
 	// associated methods to extract the information relevant to send in a create request.
-	TemplateOpts *Template `json:"-" required:"true"`
+	TemplateOpts *Template `json:"-" required:"true" stack:"template"`
 	// Enables or disables deletion of all stack resources when a stack
 	// creation fails. Default is true, meaning all resources are not deleted when
 	// stack creation fails.
 	DisableRollback *bool `json:"disable_rollback,omitempty"`
 	// A structure that contains details for the environment of the stack.
-	EnvironmentOpts *Environment `json:"-"`
+	EnvironmentOpts *Environment `json:"-" stack:"environment"`
 	// User-defined parameters to pass to the template.
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Extra headers to send with the request, e.g. correlation IDs required by
+	// a gateway. They take precedence over the MoreHeaders of the client.
+	MoreHeaders map[string]string `json:"-"`
 }
 
-// ToStackPreviewMap casts a PreviewOpts struct to a map.
-func (opts PreviewOpts) ToStackPreviewMap() (map[string]interface{}, error) {
-	b, err := gophercloud.BuildRequestBody(opts, "")
-	if err != nil {
-		return nil, err
-	}
-
-	if err := opts.TemplateOpts.Parse(); err != nil {
-		return nil, err
-	}
+// ToStackMoreHeaders returns the extra headers to send with the request.
+func (opts PreviewOpts) ToStackMoreHeaders() map[string]string {
+	return opts.MoreHeaders
+}
 
-	if err := opts.TemplateOpts.getFileContents(opts.TemplateOpts.Parsed, ignoreIfTemplate, true); err != nil {
-		return nil, err
+// ToCreateOpts returns CreateOpts creating the stack described by the
+// PreviewOpts, so that an approved preview can be created as-is. The template,
+// environment and parameters are copied so that modifying the returned
+// CreateOpts does not alter the PreviewOpts, and vice versa.
+func (opts PreviewOpts) ToCreateOpts() CreateOpts {
+	createOpts := CreateOpts{
+		Name:    opts.Name,
+		Timeout: opts.Timeout,
 	}
-	opts.TemplateOpts.fixFileRefs()
-	b["template"] = string(opts.TemplateOpts.Bin)
 
-	files := make(map[string]string)
-	for k, v := range opts.TemplateOpts.Files {
-		files[k] = v
+	if opts.TemplateOpts != nil {
+		createOpts.TemplateOpts = &Template{TE: opts.TemplateOpts.TE.clone()}
 	}
-
 	if opts.EnvironmentOpts != nil {
-		if err := opts.EnvironmentOpts.Parse(); err != nil {
-			return nil, err
-		}
-		if err := opts.EnvironmentOpts.getRRFileContents(ignoreIfEnvironment); err != nil {
-			return nil, err
-		}
-		opts.EnvironmentOpts.fixFileRefs()
-		for k, v := range opts.EnvironmentOpts.Files {
-			files[k] = v
-		}
-		b["environment"] = string(opts.EnvironmentOpts.Bin)
+		createOpts.EnvironmentOpts = &Environment{TE: opts.EnvironmentOpts.TE.clone()}
 	}
-
-	if len(files) > 0 {
-		b["files"] = files
+	if opts.DisableRollback != nil {
+		disableRollback := *opts.DisableRollback
+		createOpts.DisableRollback = &disableRollback
+	}
+	if opts.Parameters != nil {
+		createOpts.Parameters = deepCopy(opts.Parameters).(map[string]interface{})
+	}
+	if opts.MoreHeaders != nil {
+		createOpts.MoreHeaders = copyStringMap(opts.MoreHeaders)
 	}
 
-	return b, nil
+	return createOpts
+}
+
+// ToStackPreviewMap casts a PreviewOpts struct to a map.
+func (opts PreviewOpts) ToStackPreviewMap() (map[string]interface{}, error) {
+	return buildStackMap(opts)
 }
 
 // Preview accepts a PreviewOptsBuilder interface and creates a preview of a stack using the values
 // provided.
 func Preview(c *gophercloud.ServiceClient, opts PreviewOptsBuilder) (r PreviewResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
 	b, err := opts.ToStackPreviewMap()
 	if err != nil {
 		r.Err = err
 		return
 	}
 	_, r.Err = c.Post(previewURL(c), b, &r.Body, &gophercloud.RequestOpts{
-		OkCodes: []int{200},
+		Timeout:     requestTimeout(c),
+		OkCodes:     []int{200},
+		MoreHeaders: moreHeaders(opts),
 	})
 	return
 }
@@ -468,9 +1062,16 @@ func Preview(c *gophercloud.ServiceClient, opts PreviewOptsBuilder) (r PreviewRe
 // Abandon deletes the stack with the provided stackName and stackID, but leaves its
 // resources intact, and returns data describing the stack and its resources.
 func Abandon(c *gophercloud.ServiceClient, stackName, stackID string) (r AbandonResult) {
+	if err := checkEndpoint(c); err != nil {
+		r.Err = err
+		return
+	}
+
 	_, r.Err = c.Delete(abandonURL(c, stackName, stackID), &gophercloud.RequestOpts{
+		Timeout:      requestTimeout(c),
 		JSONResponse: &r.Body,
 		OkCodes:      []int{200},
+		ErrorContext: stackErrorContext{},
 	})
 	return
 }
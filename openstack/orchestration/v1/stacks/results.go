@@ -1,7 +1,14 @@
 package stacks
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud"
@@ -29,6 +36,44 @@ func (r CreateResult) Extract() (*CreatedStack, error) {
 	return s.CreatedStack, err
 }
 
+// enveloped returns the value under key of a decoded response body, or nil.
+func enveloped(body interface{}, key string) interface{} {
+	if m, ok := body.(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
+
+// StackID returns the ID of the created stack. It is taken from the Location
+// header Heat sets to the URL of the new stack, falling back to the response
+// body when the header is absent.
+func (r CreateResult) StackID() (string, error) {
+	if r.Err != nil {
+		return "", r.Err
+	}
+
+	if location := r.Header.Get("Location"); location != "" {
+		u, err := url.Parse(location)
+		if err != nil {
+			return "", err
+		}
+		id := path.Base(u.Path)
+		if id == "." || id == "/" {
+			return "", fmt.Errorf("Failed to parse the ID of the created stack: %s", location)
+		}
+		return id, nil
+	}
+
+	stack, err := r.Extract()
+	if err != nil {
+		return "", err
+	}
+	if stack == nil {
+		return "", fmt.Errorf("Failed to find the ID of the created stack")
+	}
+	return stack.ID, nil
+}
+
 // AdoptResult represents the result of an Adopt operation. AdoptResult has the
 // same form as CreateResult.
 type AdoptResult struct {
@@ -46,6 +91,35 @@ func (r StackPage) IsEmpty() (bool, error) {
 	return len(stacks) == 0, err
 }
 
+// stackMarkerPage is a page of stacks paged by marker, for the functions of
+// this package that need to list every stack.
+type stackMarkerPage struct {
+	pagination.MarkerPageBase
+}
+
+// IsEmpty returns true if the page contains no stacks.
+func (r stackMarkerPage) IsEmpty() (bool, error) {
+	stacks, err := r.extract()
+	return len(stacks) == 0, err
+}
+
+// LastMarker returns the ID of the last stack of the page.
+func (r stackMarkerPage) LastMarker() (string, error) {
+	stacks, err := r.extract()
+	if err != nil || len(stacks) == 0 {
+		return "", err
+	}
+	return stacks[len(stacks)-1].ID, nil
+}
+
+func (r stackMarkerPage) extract() ([]ListedStack, error) {
+	var s struct {
+		ListedStacks []ListedStack `json:"stacks"`
+	}
+	err := r.ExtractInto(&s)
+	return s.ListedStacks, err
+}
+
 // ListedStack represents an element in the slice extracted from a List operation.
 type ListedStack struct {
 	CreationTime time.Time          `json:"-"`
@@ -59,20 +133,29 @@ type ListedStack struct {
 	UpdatedTime  time.Time          `json:"-"`
 }
 
-func (r *ListedStack) UnmarshalJSON(b []byte) error {
-	type tmp ListedStack
-	var s struct {
-		tmp
-		CreationTime string `json:"creation_time"`
-		UpdatedTime  string `json:"updated_time"`
-	}
+type listedStack ListedStack
 
+// listedStackJSON is the form a ListedStack is decoded from.
+type listedStackJSON struct {
+	listedStack
+	CreationTime string          `json:"creation_time"`
+	UpdatedTime  string          `json:"updated_time"`
+	Tags         json.RawMessage `json:"tags"`
+}
+
+func (r *ListedStack) UnmarshalJSON(b []byte) error {
+	var s listedStackJSON
 	err := json.Unmarshal(b, &s)
 	if err != nil {
 		return err
 	}
 
-	*r = ListedStack(s.tmp)
+	*r = ListedStack(s.listedStack)
+
+	r.Tags, err = parseTags(s.Tags)
+	if err != nil {
+		return err
+	}
 
 	if s.CreationTime != "" {
 		t, err := time.Parse(time.RFC3339, s.CreationTime)
@@ -99,6 +182,53 @@ func (r *ListedStack) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Action returns the action part of the stack's status, e.g. UPDATE for
+// UPDATE_FAILED.
+func (r ListedStack) Action() string {
+	action, _ := SplitStatus(r.Status)
+	return action
+}
+
+// State returns the state part of the stack's status, e.g. FAILED for
+// UPDATE_FAILED.
+func (r ListedStack) State() string {
+	_, state := SplitStatus(r.Status)
+	return state
+}
+
+// SplitStatus splits a stack or resource status as reported by Heat, e.g.
+// ROLLBACK_IN_PROGRESS, into its action, ROLLBACK, and its state,
+// IN_PROGRESS. A status without an action is returned as the state.
+func SplitStatus(status string) (action, state string) {
+	i := strings.Index(status, "_")
+	if i < 0 {
+		return "", status
+	}
+	return status[:i], status[i+1:]
+}
+
+// parseTags decodes stack tags, which depending on the Heat version are
+// returned either as an array or as a comma-joined string.
+func parseTags(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var tags []string
+	if err := json.Unmarshal(raw, &tags); err == nil {
+		return tags, nil
+	}
+
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil {
+		return nil, err
+	}
+	if joined == "" {
+		return nil, nil
+	}
+	return strings.Split(joined, ","), nil
+}
+
 // ExtractStacks extracts and returns a slice of ListedStack. It is used while iterating
 // over a stacks.List call.
 func ExtractStacks(r pagination.Page) ([]ListedStack, error) {
@@ -120,29 +250,50 @@ type RetrievedStack struct {
 	NotificationTopics  []interface{}            `json:"notification_topics"`
 	Outputs             []map[string]interface{} `json:"outputs"`
 	Parameters          map[string]string        `json:"parameters"`
+	Parent              string                   `json:"parent"`
 	Name                string                   `json:"stack_name"`
 	Status              string                   `json:"stack_status"`
 	StatusReason        string                   `json:"stack_status_reason"`
+	StackUserProjectID  string                   `json:"stack_user_project_id"`
 	Tags                []string                 `json:"tags"`
 	TemplateDescription string                   `json:"template_description"`
 	Timeout             int                      `json:"timeout_mins"`
-	UpdatedTime         time.Time                `json:"-"`
+	// PreviousTemplate is the template the stack had before its last update,
+	// on Heat deployments that retain it.
+	PreviousTemplate map[string]interface{} `json:"previous_template"`
+	// UpdatedTime is the time of the last update of the stack, or nil if it
+	// has never been updated.
+	UpdatedTime *time.Time `json:"-"`
+}
+
+type retrievedStack RetrievedStack
+
+// retrievedStackJSON is the form a RetrievedStack is decoded from.
+type retrievedStackJSON struct {
+	retrievedStack
+	CreationTime string          `json:"creation_time"`
+	UpdatedTime  string          `json:"updated_time"`
+	Tags         json.RawMessage `json:"tags"`
 }
 
 func (r *RetrievedStack) UnmarshalJSON(b []byte) error {
-	type tmp RetrievedStack
-	var s struct {
-		tmp
-		CreationTime string `json:"creation_time"`
-		UpdatedTime  string `json:"updated_time"`
-	}
+	var s retrievedStackJSON
 
-	err := json.Unmarshal(b, &s)
+	// Numbers are kept as json.Number so that large integers given as
+	// outputs, e.g. 64-bit IDs, don't lose precision.
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	err := decoder.Decode(&s)
 	if err != nil {
 		return err
 	}
 
-	*r = RetrievedStack(s.tmp)
+	*r = RetrievedStack(s.retrievedStack)
+
+	r.Tags, err = parseTags(s.Tags)
+	if err != nil {
+		return err
+	}
 
 	if s.CreationTime != "" {
 		t, err := time.Parse(time.RFC3339, s.CreationTime)
@@ -163,20 +314,191 @@ func (r *RetrievedStack) UnmarshalJSON(b []byte) error {
 				return err
 			}
 		}
-		r.UpdatedTime = t
+		r.UpdatedTime = &t
 	}
 
 	return nil
 }
 
+// Action returns the action part of the stack's status, e.g. UPDATE for
+// UPDATE_FAILED.
+func (r RetrievedStack) Action() string {
+	action, _ := SplitStatus(r.Status)
+	return action
+}
+
+// State returns the state part of the stack's status, e.g. FAILED for
+// UPDATE_FAILED.
+func (r RetrievedStack) State() string {
+	_, state := SplitStatus(r.Status)
+	return state
+}
+
+// Output represents an output of a stack.
+type Output struct {
+	// Key is the name of the output.
+	Key string `json:"output_key"`
+	// Value is the resolved value of the output.
+	Value interface{} `json:"output_value"`
+	// Description is the description of the output.
+	Description string `json:"description"`
+	// OutputError is the reason the output could not be resolved, if any.
+	OutputError string `json:"output_error"`
+}
+
+// Output returns the stack output with the given key. If Heat could not
+// resolve the output, the output is returned along with an ErrOutputError
+// describing why.
+func (r RetrievedStack) Output(key string) (*Output, error) {
+	for _, o := range r.Outputs {
+		if k, _ := o["output_key"].(string); k != key {
+			continue
+		}
+		b, err := json.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		var output Output
+		if err := json.Unmarshal(b, &output); err != nil {
+			return nil, err
+		}
+		if output.OutputError != "" {
+			return &output, ErrOutputError{Key: key, Reason: output.OutputError}
+		}
+		return &output, nil
+	}
+	return nil, ErrOutputNotFound{Key: key}
+}
+
+// outputValue returns the value of the stack output with the given key as
+// decoded from the response, with the errors of Output.
+func (r RetrievedStack) outputValue(key string) (interface{}, error) {
+	if _, err := r.Output(key); err != nil {
+		return nil, err
+	}
+	for _, o := range r.Outputs {
+		if k, _ := o["output_key"].(string); k == key {
+			return o["output_value"], nil
+		}
+	}
+	return nil, ErrOutputNotFound{Key: key}
+}
+
+// OutputString returns the value of the stack output with the given key as a
+// string. It returns an ErrOutputType if the value is not a string.
+func (r RetrievedStack) OutputString(key string) (string, error) {
+	value, err := r.outputValue(key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", ErrOutputType{Key: key, Type: "string", Value: value}
+	}
+	return s, nil
+}
+
+// OutputInt returns the value of the stack output with the given key as an
+// int. Integral numbers and strings holding one are converted; other values
+// yield an ErrOutputType.
+func (r RetrievedStack) OutputInt(key string) (int, error) {
+	value, err := r.outputValue(key)
+	if err != nil {
+		return 0, err
+	}
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(v.String()); err == nil {
+			return i, nil
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+	}
+	return 0, ErrOutputType{Key: key, Type: "int", Value: value}
+}
+
+// OutputStringSlice returns the value of the stack output with the given key
+// as a slice of strings. It returns an ErrOutputType if the value is not a
+// list or holds anything but strings.
+func (r RetrievedStack) OutputStringSlice(key string) ([]string, error) {
+	value, err := r.outputValue(key)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, ErrOutputType{Key: key, Type: "list of strings", Value: value}
+	}
+	strs := make([]string, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, ErrOutputType{Key: key, Type: "list of strings", Value: value}
+		}
+		strs[i] = s
+	}
+	return strs, nil
+}
+
+// OutputMap returns the value of the stack output with the given key as a
+// map. It returns an ErrOutputType if the value is not a map.
+func (r RetrievedStack) OutputMap(key string) (map[string]interface{}, error) {
+	value, err := r.outputValue(key)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, ErrOutputType{Key: key, Type: "map", Value: value}
+	}
+	return m, nil
+}
+
+// StackDetailPage is a pagination.Pager that is returned from a call to the
+// ListDetail function.
+type StackDetailPage struct {
+	pagination.SinglePageBase
+}
+
+// IsEmpty returns true if a StackDetailPage contains no Stacks.
+func (r StackDetailPage) IsEmpty() (bool, error) {
+	stacks, err := ExtractStacksDetail(r)
+	return len(stacks) == 0, err
+}
+
+// ExtractStacksDetail extracts and returns a slice of RetrievedStack. It is
+// used while iterating over a stacks.ListDetail call.
+func ExtractStacksDetail(r pagination.Page) ([]RetrievedStack, error) {
+	var s struct {
+		Stacks []RetrievedStack `json:"stacks"`
+	}
+	err := (r.(StackDetailPage)).ExtractInto(&s)
+	return s.Stacks, err
+}
+
 // GetResult represents the result of a Get operation.
 type GetResult struct {
 	gophercloud.Result
 }
 
 // Extract returns a pointer to a RetrievedStack object and is called after a
-// Get operation.
+// Get operation. Depending on its version, Heat returns the stack either
+// under a "stack" key or as the bare object; both are accepted.
 func (r GetResult) Extract() (*RetrievedStack, error) {
+	if body, ok := r.Body.(map[string]interface{}); ok && r.Err == nil {
+		if _, hasEnvelope := body["stack"]; !hasEnvelope {
+			var s *RetrievedStack
+			err := r.ExtractInto(&s)
+			return s, err
+		}
+	}
+
 	var s struct {
 		Stack *RetrievedStack `json:"stack"`
 	}
@@ -189,11 +511,41 @@ type UpdateResult struct {
 	gophercloud.ErrResult
 }
 
+// ResourceChanges represents the changes to the resources of a stack
+// reported by an UpdatePreview operation.
+type ResourceChanges struct {
+	Added     []PreviewedResource `json:"added"`
+	Deleted   []PreviewedResource `json:"deleted"`
+	Replaced  []PreviewedResource `json:"replaced"`
+	Unchanged []PreviewedResource `json:"unchanged"`
+	Updated   []PreviewedResource `json:"updated"`
+}
+
+// UpdatePreviewResult represents the result of an UpdatePreview operation.
+type UpdatePreviewResult struct {
+	gophercloud.Result
+}
+
+// Extract returns a pointer to a ResourceChanges object and is called after
+// an UpdatePreview operation.
+func (r UpdatePreviewResult) Extract() (*ResourceChanges, error) {
+	var s struct {
+		ResourceChanges *ResourceChanges `json:"resource_changes"`
+	}
+	err := r.ExtractInto(&s)
+	return s.ResourceChanges, err
+}
+
 // DeleteResult represents the result of a Delete operation.
 type DeleteResult struct {
 	gophercloud.ErrResult
 }
 
+// ActionResult represents the result of a stack action, such as CancelUpdate.
+type ActionResult struct {
+	gophercloud.ErrResult
+}
+
 // PreviewedStack represents the result of a Preview operation.
 type PreviewedStack struct {
 	Capabilities        []interface{}      `json:"capabilities"`
@@ -266,20 +618,171 @@ func (r PreviewResult) Extract() (*PreviewedStack, error) {
 	return s.PreviewedStack, err
 }
 
+// PreviewedResource represents a resource of a PreviewedStack.
+type PreviewedResource struct {
+	Name              string                 `json:"resource_name"`
+	Type              string                 `json:"resource_type"`
+	LogicalResourceID string                 `json:"logical_resource_id"`
+	Properties        map[string]interface{} `json:"properties"`
+	RequiredBy        []string               `json:"required_by"`
+	// Condition is the value the resource's template condition evaluated to.
+	// It is nil if the resource has no condition or if Heat did not report
+	// it.
+	Condition *bool `json:"condition"`
+}
+
+// Enabled reports whether the resource would be created, that is whether it
+// is not disabled by a template condition. Resources are assumed to be
+// enabled when Heat does not report their condition.
+func (r PreviewedResource) Enabled() bool {
+	return r.Condition == nil || *r.Condition
+}
+
+// ExtractResources interprets the Resources of a PreviewedStack as a slice of
+// PreviewedResource. Heat previews the resources of nested stacks as nested
+// lists; these are flattened into the result.
+func (r PreviewedStack) ExtractResources() ([]PreviewedResource, error) {
+	var resources []PreviewedResource
+	var walk func(items []interface{}) error
+	walk = func(items []interface{}) error {
+		for _, item := range items {
+			if nested, ok := item.([]interface{}); ok {
+				if err := walk(nested); err != nil {
+					return err
+				}
+				continue
+			}
+
+			b, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			var resource PreviewedResource
+			if err := json.Unmarshal(b, &resource); err != nil {
+				return err
+			}
+			resources = append(resources, resource)
+		}
+		return nil
+	}
+
+	err := walk(r.Resources)
+	return resources, err
+}
+
 // AbandonedStack represents the result of an Abandon operation.
 type AbandonedStack struct {
-	Status             string                 `json:"status"`
-	Name               string                 `json:"name"`
-	Template           map[string]interface{} `json:"template"`
-	Action             string                 `json:"action"`
-	ID                 string                 `json:"id"`
-	Resources          map[string]interface{} `json:"resources"`
-	Files              map[string]string      `json:"files"`
-	StackUserProjectID string                 `json:"stack_user_project_id"`
-	ProjectID          string                 `json:"project_id"`
-	Environment        map[string]interface{} `json:"environment"`
+	Status             string                       `json:"status"`
+	Name               string                       `json:"name"`
+	Template           map[string]interface{}       `json:"template"`
+	Action             string                       `json:"action"`
+	ID                 string                       `json:"id"`
+	Resources          map[string]AbandonedResource `json:"resources"`
+	Files              map[string]string            `json:"files"`
+	StackUserProjectID string                       `json:"stack_user_project_id"`
+	ProjectID          string                       `json:"project_id"`
+	Environment        map[string]interface{}       `json:"environment"`
+}
+
+// ToAdoptOpts returns the options to adopt the abandoned stack under the given
+// name, e.g. in another cloud. The template and environment of the stack are
+// sent along with the abandoned data, and the files they reference are taken
+// from Files rather than fetched again.
+func (s AbandonedStack) ToAdoptOpts(name string) (AdoptOpts, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return AdoptOpts{}, err
+	}
+	opts := AdoptOpts{
+		AdoptStackData: string(data),
+		Name:           name,
+	}
+
+	template, err := json.Marshal(s.Template)
+	if err != nil {
+		return AdoptOpts{}, err
+	}
+	opts.TemplateOpts = &Template{TE: TE{Bin: template, client: filesClient(s.Files)}}
+
+	if len(s.Environment) > 0 {
+		environment, err := json.Marshal(s.Environment)
+		if err != nil {
+			return AdoptOpts{}, err
+		}
+		opts.EnvironmentOpts = &Environment{TE: TE{Bin: environment, client: filesClient(s.Files)}}
+	}
+
+	return opts, nil
 }
 
+// AbandonedResource represents a resource of an abandoned stack. Changing
+// ResourceID before adopting the stack allows physical resources to be
+// re-mapped, e.g. to the network UUIDs of another cloud.
+type AbandonedResource struct {
+	Name         string                 `json:"name"`
+	Type         string                 `json:"type"`
+	Action       string                 `json:"action"`
+	Status       string                 `json:"status"`
+	ResourceID   string                 `json:"resource_id"`
+	ResourceData map[string]interface{} `json:"resource_data,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	// Extra holds the fields of the resource that are not modeled above, so
+	// that they are preserved when the resource is encoded again.
+	Extra map[string]interface{} `json:"-"`
+}
+
+func (r *AbandonedResource) UnmarshalJSON(b []byte) error {
+	type tmp AbandonedResource
+	var s tmp
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	var extra map[string]interface{}
+	if err := json.Unmarshal(b, &extra); err != nil {
+		return err
+	}
+	for _, k := range abandonedResourceFields {
+		delete(extra, k)
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+
+	*r = AbandonedResource(s)
+	r.Extra = extra
+	return nil
+}
+
+func (r AbandonedResource) MarshalJSON() ([]byte, error) {
+	type tmp AbandonedResource
+	b, err := json.Marshal(tmp(r))
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	// Keep empty maps, which omitempty would drop, as Heat expects them.
+	if r.ResourceData != nil {
+		m["resource_data"] = r.ResourceData
+	}
+	if r.Metadata != nil {
+		m["metadata"] = r.Metadata
+	}
+	for k, v := range r.Extra {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+// abandonedResourceFields are the keys modeled by AbandonedResource.
+var abandonedResourceFields = []string{"name", "type", "action", "status", "resource_id", "resource_data", "metadata"}
+
 // AbandonResult represents the result of an Abandon operation.
 type AbandonResult struct {
 	gophercloud.Result
@@ -299,3 +802,69 @@ func (r AbandonResult) String() (string, error) {
 	out, err := json.Marshal(r)
 	return string(out), err
 }
+
+// StackSnapshot represents a snapshot of a stack.
+type StackSnapshot struct {
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Status       string                 `json:"status"`
+	StatusReason string                 `json:"status_reason"`
+	Data         map[string]interface{} `json:"data"`
+	CreationTime time.Time              `json:"-"`
+}
+
+func (r *StackSnapshot) UnmarshalJSON(b []byte) error {
+	type tmp StackSnapshot
+	var s struct {
+		tmp
+		CreationTime string `json:"creation_time"`
+	}
+
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	*r = StackSnapshot(s.tmp)
+
+	if s.CreationTime != "" {
+		t, err := time.Parse(time.RFC3339, s.CreationTime)
+		if err != nil {
+			t, err = time.Parse(gophercloud.RFC3339NoZ, s.CreationTime)
+			if err != nil {
+				return err
+			}
+		}
+		r.CreationTime = t
+	}
+
+	return nil
+}
+
+// SnapshotResult represents the result of a Snapshot operation.
+type SnapshotResult struct {
+	gophercloud.Result
+}
+
+// Extract returns a pointer to a StackSnapshot object and is called after a
+// Snapshot operation.
+func (r SnapshotResult) Extract() (*StackSnapshot, error) {
+	var s *StackSnapshot
+	err := r.ExtractInto(&s)
+	return s, err
+}
+
+// GetSnapshotResult represents the result of a GetSnapshot operation.
+type GetSnapshotResult struct {
+	gophercloud.Result
+}
+
+// Extract returns a pointer to a StackSnapshot object and is called after a
+// GetSnapshot operation.
+func (r GetSnapshotResult) Extract() (*StackSnapshot, error) {
+	var s struct {
+		Snapshot *StackSnapshot `json:"snapshot"`
+	}
+	err := r.ExtractInto(&s)
+	return s.Snapshot, err
+}
@@ -0,0 +1,161 @@
+package stacks
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/pagination"
+)
+
+// ExtractStrict is like Extract, but also returns an ErrUnknownFields if Heat
+// returned fields that CreatedStack does not model. It is meant to help detect
+// drift between Heat and gophercloud, e.g. in tests run against a new Heat
+// release.
+func (r CreateResult) ExtractStrict() (*CreatedStack, error) {
+	s, err := r.Extract()
+	if err != nil {
+		return s, err
+	}
+	return s, checkUnknownFields(enveloped(r.Body, "stack"), &CreatedStack{}, "CreatedStack")
+}
+
+// ExtractStrict is like Extract, but also returns an ErrUnknownFields if Heat
+// returned fields that RetrievedStack does not model.
+func (r GetResult) ExtractStrict() (*RetrievedStack, error) {
+	s, err := r.Extract()
+	if err != nil {
+		return s, err
+	}
+	body := r.Body
+	if stack := enveloped(body, "stack"); stack != nil {
+		body = stack
+	}
+	return s, checkUnknownFields(body, &retrievedStackJSON{}, "RetrievedStack")
+}
+
+// ExtractStacksStrict is like ExtractStacks, but also returns an
+// ErrUnknownFields if Heat returned fields that ListedStack does not model.
+func ExtractStacksStrict(r pagination.Page) ([]ListedStack, error) {
+	stacks, err := ExtractStacks(r)
+	if err != nil {
+		return stacks, err
+	}
+	items, _ := enveloped(r.(StackPage).Body, "stacks").([]interface{})
+	for _, item := range items {
+		if err := checkUnknownFields(item, &listedStackJSON{}, "ListedStack"); err != nil {
+			return stacks, err
+		}
+	}
+	return stacks, nil
+}
+
+// checkUnknownFields returns an ErrUnknownFields naming every field of body,
+// at any depth, that v does not model. typeName is the name reported in the
+// error.
+func checkUnknownFields(body interface{}, v interface{}, typeName string) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	unknown := unknownFields(b, reflect.TypeOf(v))
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return ErrUnknownFields{Type: typeName, Fields: unknown}
+}
+
+// unknownFields returns the names of the object keys of b that t does not
+// model, checking one level at a time the fields, and slice elements, that t
+// models with a struct. Types decoding themselves, maps and interfaces accept
+// any key.
+func unknownFields(b []byte, t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(unmarshalerType) || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(b, &items); err != nil {
+			return nil
+		}
+		for _, item := range items {
+			unknown = append(unknown, unknownFields(item, t.Elem())...)
+		}
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(b, &object); err != nil {
+			return nil
+		}
+		fields := jsonFields(t)
+		for key, value := range object {
+			field, ok := fields[key]
+			if !ok {
+				// encoding/json matches keys case-insensitively.
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = f, true
+						break
+					}
+				}
+			}
+			if !ok {
+				unknown = append(unknown, key)
+				continue
+			}
+			unknown = append(unknown, unknownFields(value, field)...)
+		}
+	}
+	return unknown
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// jsonFields returns the types of the fields of the struct type t by their
+// JSON name, including the fields promoted from untagged embedded structs.
+// Fields of t take precedence over the promoted ones.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	for _, e := range embedded {
+		for name, ft := range jsonFields(e) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = ft
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,90 @@
+package stacks
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/objectstorage/v1/objects"
+)
+
+// swiftTemplateObject is the name of the object CreateViaSwift uploads the
+// template as.
+const swiftTemplateObject = "template.yaml"
+
+// CreateViaSwift creates a stack like Create, but rather than inlining the
+// template and the files it and the environment reference in the request, it
+// uploads them to the given container of objectStore, which must exist. Heat
+// is given the template as a template_url and fetches the files through
+// files_container. This keeps the request small for large templates or stacks
+// bundling many or large files. The container must be readable by Heat.
+//
+// If uploading an object or creating the stack fails, the objects uploaded so
+// far are deleted.
+func CreateViaSwift(c *gophercloud.ServiceClient, objectStore *gophercloud.ServiceClient, container string, opts CreateOpts) (r CreateResult) {
+	b, err := opts.ToStackCreateMap()
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	files, _ := b["files"].(map[string]string)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var uploaded []string
+	cleanup := func() {
+		for _, name := range uploaded {
+			objects.Delete(objectStore, container, name, nil)
+		}
+	}
+	upload := func(name, content string) error {
+		err := objects.Create(objectStore, container, name, objects.CreateOpts{
+			Content: strings.NewReader(content),
+		}).Err
+		if err != nil {
+			cleanup()
+			return err
+		}
+		uploaded = append(uploaded, name)
+		return nil
+	}
+
+	if template, ok := b["template"].(string); ok {
+		if err := upload(swiftTemplateObject, template); err != nil {
+			r.Err = err
+			return
+		}
+		delete(b, "template")
+		b["template_url"] = objectStore.ServiceURL(container, swiftTemplateObject)
+	}
+	for _, name := range names {
+		if err := upload(name, files[name]); err != nil {
+			r.Err = err
+			return
+		}
+	}
+
+	delete(b, "files")
+	b["files_container"] = container
+	opts.RawBody = b
+
+	r = Create(c, opts)
+	if r.Err != nil {
+		cleanup()
+	}
+	return
+}
+
+// FetchTemplateFromObjectStore downloads a template from the given container
+// of objectStore, using the credentials of objectStore, e.g. for a template
+// kept in a private container that Heat could not fetch from a template_url.
+// The content is meant to be used as the Bin of the TemplateOpts of
+// CreateOpts, or of the other options of this package.
+func FetchTemplateFromObjectStore(objectStore *gophercloud.ServiceClient, container, object string) ([]byte, error) {
+	r := objects.Download(objectStore, container, object, nil)
+	return r.ExtractContent()
+}
@@ -3,9 +3,11 @@ package stacks
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/gophercloud/gophercloud"
+	"gopkg.in/yaml.v2"
 )
 
 // Template is a structure that represents OpenStack Heat templates
@@ -21,6 +23,47 @@ var TemplateFormatVersions = map[string]bool{
 	"AWSTemplateFormatVersion":  true,
 }
 
+// IntrinsicFunctions is a map containing the names of the intrinsic functions
+// of HOT and CFN templates.
+var IntrinsicFunctions = map[string]bool{
+	"and":                 true,
+	"contains":            true,
+	"digest":              true,
+	"equals":              true,
+	"filter":              true,
+	"get_attr":            true,
+	"get_file":            true,
+	"get_param":           true,
+	"get_resource":        true,
+	"if":                  true,
+	"list_concat":         true,
+	"list_concat_unique":  true,
+	"list_join":           true,
+	"make_url":            true,
+	"map_merge":           true,
+	"map_replace":         true,
+	"not":                 true,
+	"or":                  true,
+	"repeat":              true,
+	"resource_facade":     true,
+	"str_replace":         true,
+	"str_replace_strict":  true,
+	"str_replace_vstrict": true,
+	"str_split":           true,
+	"yaql":                true,
+	"Fn::Base64":          true,
+	"Fn::FindInMap":       true,
+	"Fn::GetAtt":          true,
+	"Fn::GetAZs":          true,
+	"Fn::Join":            true,
+	"Fn::MemberListToMap": true,
+	"Fn::Replace":         true,
+	"Fn::ResourceFacade":  true,
+	"Fn::Select":          true,
+	"Fn::Split":           true,
+	"Ref":                 true,
+}
+
 // Validate validates the contents of the Template
 func (t *Template) Validate() error {
 	if t.Parsed == nil {
@@ -139,3 +182,185 @@ func ignoreIfTemplate(key string, value interface{}) bool {
 	}
 	return false
 }
+
+// UsedFunctions returns the sorted names of the intrinsic functions used in
+// the template, including those nested inside other functions, lists and
+// maps. The template must have been parsed.
+func (t *Template) UsedFunctions() []string {
+	used := make(map[string]bool)
+	collectFunctions(t.Parsed, used)
+
+	functions := make([]string, 0, len(used))
+	for f := range used {
+		functions = append(functions, f)
+	}
+	sort.Strings(functions)
+	return functions
+}
+
+// collectFunctions walks te and records the intrinsic functions it finds in
+// used. A function is a map with a single key naming the function.
+func collectFunctions(te interface{}, used map[string]bool) {
+	switch te.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		teMap, err := toStringKeys(te)
+		if err != nil {
+			return
+		}
+		for k, v := range teMap {
+			if len(teMap) == 1 && IntrinsicFunctions[k] {
+				used[k] = true
+			}
+			collectFunctions(v, used)
+		}
+	case []interface{}:
+		for _, v := range te.([]interface{}) {
+			collectFunctions(v, used)
+		}
+	}
+}
+
+// MarshalPreservingOrder marshals the template to YAML keeping the order of
+// the keys in Bin, so that the output of a load, edit and save cycle diffs
+// cleanly against the original. Edits made to Parsed are applied on top of
+// Bin: values in Parsed replace those in Bin, keys only found in Parsed are
+// added after the existing ones in sorted order and keys missing from Parsed
+// are removed. If the template has not been parsed, Bin is only reformatted.
+func (t *Template) MarshalPreservingOrder() ([]byte, error) {
+	if err := t.Fetch(); err != nil {
+		return nil, err
+	}
+
+	var ordered yaml.MapSlice
+	if err := yaml.Unmarshal(t.Bin, &ordered); err != nil {
+		return nil, ErrInvalidDataFormat{}
+	}
+
+	var merged interface{} = ordered
+	if t.Parsed != nil {
+		merged = mergeOrdered(ordered, t.Parsed)
+	}
+	return yaml.Marshal(merged)
+}
+
+// mergeOrdered returns edited, with the maps it contains ordered like their
+// counterparts in the ordered representation original.
+func mergeOrdered(original, edited interface{}) interface{} {
+	switch e := edited.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		o, ok := original.(yaml.MapSlice)
+		if !ok {
+			return edited
+		}
+		editedMap, err := toStringKeys(e)
+		if err != nil {
+			return edited
+		}
+
+		merged := make(yaml.MapSlice, 0, len(editedMap))
+		seen := make(map[string]bool, len(o))
+		for _, item := range o {
+			key := fmt.Sprint(item.Key)
+			seen[key] = true
+			if v, ok := editedMap[key]; ok {
+				merged = append(merged, yaml.MapItem{Key: item.Key, Value: mergeOrdered(item.Value, v)})
+			}
+		}
+
+		var added []string
+		for key := range editedMap {
+			if !seen[key] {
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		for _, key := range added {
+			merged = append(merged, yaml.MapItem{Key: key, Value: editedMap[key]})
+		}
+		return merged
+	case []interface{}:
+		o, ok := original.([]interface{})
+		if !ok {
+			return edited
+		}
+		merged := make([]interface{}, len(e))
+		for i, v := range e {
+			if i < len(o) {
+				merged[i] = mergeOrdered(o[i], v)
+			} else {
+				merged[i] = v
+			}
+		}
+		return merged
+	}
+	return edited
+}
+
+// RenderParameters parses template and returns it with every `get_param`
+// annotated with the value the parameter would take: the value given in
+// params or else the default of the parameter. A `{get_param: flavor}` becomes
+// `{get_param: flavor, value: m1.small}`; parameters that have neither a
+// value nor a default are left as they are. This is a best-effort local
+// render meant for documentation, not an evaluation of the template: other
+// intrinsic functions are not resolved, and a `get_param` with a path is
+// annotated with the value of the whole parameter.
+func RenderParameters(template []byte, params map[string]string) (map[string]interface{}, error) {
+	t := new(Template)
+	t.Bin = template
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for name, schema := range templateParameters(t.Parsed) {
+		schemaMap, err := toStringKeys(schema)
+		if err != nil {
+			continue
+		}
+		if v, ok := parameterDefault(schemaMap); ok {
+			values[name] = v
+		}
+	}
+	for name, v := range params {
+		values[name] = v
+	}
+
+	rendered, _ := renderParameters(t.Parsed, values).(map[string]interface{})
+	return rendered, nil
+}
+
+// renderParameters returns a copy of te, with string keys, in which the
+// `get_param` functions are annotated with their values.
+func renderParameters(te interface{}, values map[string]interface{}) interface{} {
+	switch te.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		teMap, err := toStringKeys(te)
+		if err != nil {
+			return te
+		}
+		rendered := make(map[string]interface{}, len(teMap))
+		for k, v := range teMap {
+			rendered[k] = renderParameters(v, values)
+		}
+		if ref, ok := teMap["get_param"]; ok && len(teMap) == 1 {
+			name := ref
+			if path, ok := ref.([]interface{}); ok && len(path) > 0 {
+				name = path[0]
+			}
+			if key, ok := name.(string); ok {
+				if value, ok := values[key]; ok {
+					rendered["value"] = value
+				}
+			}
+		}
+		return rendered
+	case []interface{}:
+		items := te.([]interface{})
+		rendered := make([]interface{}, len(items))
+		for i, v := range items {
+			rendered[i] = renderParameters(v, values)
+		}
+		return rendered
+	}
+	return te
+}
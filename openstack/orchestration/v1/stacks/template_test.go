@@ -146,3 +146,131 @@ resources:
 	te.Parse()
 	th.AssertDeepEquals(t, expectedParsed, te.Parsed)
 }
+
+func TestTemplateUsedFunctions(t *testing.T) {
+	te := new(Template)
+	te.Bin = []byte(`heat_template_version: 2016-10-14
+parameters:
+  flavor:
+    type: string
+resources:
+  my_server:
+    type: OS::Nova::Server
+    properties:
+      flavor: { get_param: flavor }
+      networks:
+      - port: { get_resource: my_port }
+      user_data:
+        str_replace:
+          template: |
+            echo $ip
+          params:
+            $ip: { get_attr: [my_port, fixed_ips, 0, ip_address] }
+      metadata:
+        map_merge:
+        - { role: web }
+        - yaql:
+            expression: $.data.list.select($.name)
+            data:
+              list: []
+  my_port:
+    type: OS::Neutron::Port
+    properties:
+      network: private
+outputs:
+  ip:
+    value: { get_attr: [my_port, fixed_ips] }`)
+
+	err := te.Parse()
+	th.AssertNoErr(t, err)
+
+	expected := []string{"get_attr", "get_param", "get_resource", "map_merge", "str_replace", "yaql"}
+	th.AssertDeepEquals(t, expected, te.UsedFunctions())
+}
+
+func TestTemplateMarshalPreservingOrder(t *testing.T) {
+	original := `heat_template_version: "2016-10-14"
+description: Keeps its place
+resources:
+  server:
+    type: OS::Nova::Server
+    properties:
+      name: web
+      flavor: m1.small
+      networks:
+      - port:
+          get_resource: port
+  port:
+    type: OS::Neutron::Port
+parameters:
+  zone:
+    type: string
+`
+	te := new(Template)
+	te.Bin = []byte(original)
+	err := te.Parse()
+	th.AssertNoErr(t, err)
+
+	unchanged, err := te.MarshalPreservingOrder()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, original, string(unchanged))
+
+	resources := te.Parsed["resources"].(map[interface{}]interface{})
+	server := resources["server"].(map[interface{}]interface{})
+	properties := server["properties"].(map[interface{}]interface{})
+	properties["flavor"] = "m1.large"
+	delete(resources, "port")
+	te.Parsed["outputs"] = map[string]interface{}{"ip": "10.0.0.1"}
+
+	edited, err := te.MarshalPreservingOrder()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, `heat_template_version: "2016-10-14"
+description: Keeps its place
+resources:
+  server:
+    type: OS::Nova::Server
+    properties:
+      name: web
+      flavor: m1.large
+      networks:
+      - port:
+          get_resource: port
+parameters:
+  zone:
+    type: string
+outputs:
+  ip: 10.0.0.1
+`, string(edited))
+}
+
+func TestRenderParameters(t *testing.T) {
+	template := []byte(`heat_template_version: 2016-10-14
+parameters:
+  flavor:
+    type: string
+  image:
+    type: string
+    default: cirros
+resources:
+  server:
+    type: OS::Nova::Server
+    properties:
+      flavor: { get_param: flavor }
+      image: { get_param: image }
+      key_name: { get_param: key }
+      networks:
+      - network: { get_param: [networks, private] }
+`)
+
+	rendered, err := RenderParameters(template, map[string]string{"flavor": "m1.small"})
+	th.AssertNoErr(t, err)
+
+	resources := rendered["resources"].(map[string]interface{})
+	properties := resources["server"].(map[string]interface{})["properties"].(map[string]interface{})
+	th.AssertDeepEquals(t, map[string]interface{}{"get_param": "flavor", "value": "m1.small"}, properties["flavor"])
+	th.AssertDeepEquals(t, map[string]interface{}{"get_param": "image", "value": "cirros"}, properties["image"])
+	th.AssertDeepEquals(t, map[string]interface{}{"get_param": "key"}, properties["key_name"])
+	th.AssertDeepEquals(t, []interface{}{
+		map[string]interface{}{"network": map[string]interface{}{"get_param": []interface{}{"networks", "private"}}},
+	}, properties["networks"])
+}
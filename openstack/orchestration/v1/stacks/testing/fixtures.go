@@ -1,8 +1,14 @@
 package testing
 
 import (
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -52,6 +58,19 @@ func HandleCreateSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// HandleCreateWithLocation creates an HTTP handler at `/stacks` on the test
+// handler mux that responds with a `Create` response carrying a Location
+// header.
+func HandleCreateWithLocation(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		w.Header().Set("Location", th.Endpoint()+"stacks/stackcreated/d3c07a8e-5a5b-4f3f-9a35-7ce3c7b0e1d2")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, output)
+	})
+}
+
 // ListExpected represents the expected object from a List request.
 var ListExpected = []stacks.ListedStack{
 	{
@@ -149,6 +168,153 @@ func HandleListSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// ListUpdatedSinceNewest is the update time of the first of the stacks built
+// by ListUpdatedSinceStacks.
+var ListUpdatedSinceNewest = time.Date(2018, 6, 26, 9, 0, 0, 0, time.UTC)
+
+// ListUpdatedSinceStacks returns n stacks, the i-th one updated i hours
+// before ListUpdatedSinceNewest. Every third stack was never updated and only
+// has a creation time.
+func ListUpdatedSinceStacks(n int) []string {
+	stacks := make([]string, n)
+	for i := range stacks {
+		t := ListUpdatedSinceNewest.Add(-time.Duration(i) * time.Hour).Format(time.RFC3339)
+		times := fmt.Sprintf(`"creation_time": "2018-01-01T00:00:00Z", "updated_time": "%s"`, t)
+		if i%3 == 2 {
+			times = fmt.Sprintf(`"creation_time": "%s"`, t)
+		}
+		stacks[i] = fmt.Sprintf(`{"id": "stack-%d", "stack_name": "stack-%d", %s}`, i, i, times)
+	}
+	return stacks
+}
+
+// HandleListUpdatedSince creates an HTTP handler at `/stacks` on the test
+// handler mux that serves stacks in pages of the requested limit starting
+// after the requested marker. It returns the number of requests served.
+func HandleListUpdatedSince(t *testing.T, stacks []string) *int {
+	requests := new(int)
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		*requests++
+
+		r.ParseForm()
+		start := 0
+		if marker := r.Form.Get("marker"); marker != "" {
+			start = -1
+			for i := range stacks {
+				if strings.HasPrefix(stacks[i], fmt.Sprintf(`{"id": "%s",`, marker)) {
+					start = i + 1
+				}
+			}
+			if start < 0 {
+				t.Errorf("Unexpected marker: [%s]", marker)
+				start = len(stacks)
+			}
+		}
+		limit, _ := strconv.Atoi(r.Form.Get("limit"))
+		end := start + limit
+		if end > len(stacks) {
+			end = len(stacks)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"stacks": [%s]}`, strings.Join(stacks[start:end], ","))
+	})
+	return requests
+}
+
+// ListDetailExpected represents the expected object from a ListDetail request.
+var ListDetailExpected = []stacks.RetrievedStack{
+	{
+		DisableRollback: true,
+		Description:     "Simple template to test heat commands",
+		Parameters: map[string]string{
+			"flavor":         "m1.tiny",
+			"OS::stack_name": "postman_stack",
+			"OS::stack_id":   "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+		},
+		StatusReason: "Stack CREATE completed successfully",
+		Name:         "postman_stack",
+		Outputs: []map[string]interface{}{
+			{
+				"output_key":   "server_ip",
+				"output_value": "10.0.0.5",
+				"description":  "IP of the server",
+			},
+		},
+		CreationTime: Create_time,
+		Links: []gophercloud.Link{
+			{
+				Href: "http://166.76.160.117:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+				Rel:  "self",
+			},
+		},
+		Capabilities:        []interface{}{},
+		NotificationTopics:  []interface{}{},
+		Status:              "CREATE_COMPLETE",
+		ID:                  "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+		TemplateDescription: "Simple template to test heat commands",
+		Timeout:             60,
+		Tags:                []string{"rackspace", "atx"},
+	},
+}
+
+// ListDetailOutput represents the response body from a ListDetail request.
+const ListDetailOutput = `
+{
+  "stacks": [
+  {
+    "disable_rollback": true,
+    "description": "Simple template to test heat commands",
+    "parameters": {
+      "flavor": "m1.tiny",
+      "OS::stack_name": "postman_stack",
+      "OS::stack_id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87"
+    },
+    "stack_status_reason": "Stack CREATE completed successfully",
+    "stack_name": "postman_stack",
+    "outputs": [
+      {
+        "output_key": "server_ip",
+        "output_value": "10.0.0.5",
+        "description": "IP of the server"
+      }
+    ],
+    "creation_time": "2018-06-26T07:58:17Z",
+    "links": [
+    {
+      "href": "http://166.76.160.117:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+      "rel": "self"
+    }
+    ],
+    "capabilities": [],
+    "notification_topics": [],
+    "timeout_mins": 60,
+    "stack_status": "CREATE_COMPLETE",
+    "updated_time": null,
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "template_description": "Simple template to test heat commands",
+    "tags": ["rackspace", "atx"]
+  }
+  ]
+}
+`
+
+// HandleListDetailSuccessfully creates an HTTP handler at `/stacks/detail` on
+// the test handler mux that responds with a `ListDetail` response.
+func HandleListDetailSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks/detail", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+}
+
 // GetExpected represents the expected object from a Get request.
 var GetExpected = &stacks.RetrievedStack{
 	DisableRollback: true,
@@ -209,6 +375,186 @@ const GetOutput = `
 }
 `
 
+// GetBareOutput represents the response body from a Get request on a Heat
+// version returning the stack without the "stack" envelope.
+const GetBareOutput = `
+{
+  "disable_rollback": true,
+  "description": "Simple template to test heat commands",
+  "parameters": {
+    "flavor": "m1.tiny",
+    "OS::stack_name": "postman_stack",
+    "OS::stack_id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87"
+  },
+  "stack_status_reason": "Stack CREATE completed successfully",
+  "stack_name": "postman_stack",
+  "outputs": [],
+  "creation_time": "2018-06-26T07:58:17Z",
+  "links": [
+  {
+    "href": "http://166.76.160.117:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "rel": "self"
+  }
+  ],
+  "capabilities": [],
+  "notification_topics": [],
+  "timeout_mins": null,
+  "stack_status": "CREATE_COMPLETE",
+  "updated_time": null,
+  "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+  "template_description": "Simple template to test heat commands",
+  "tags": ["rackspace", "atx"]
+}
+`
+
+// GetExtraFieldsOutput represents the response body from a Get request on a
+// Heat version returning fields that are not modeled by RetrievedStack.
+const GetExtraFieldsOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "stack_owner": "admin",
+    "deletion_time": null,
+    "links": [
+      {
+        "href": "http://166.76.160.117:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+        "rel": "self",
+        "type": "application/json"
+      }
+    ]
+  }
+}`
+
+// GetExtraLinkFieldsOutput represents the response body from a Get request
+// on a Heat version returning links with a field named like a field of the
+// stack itself.
+const GetExtraLinkFieldsOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "links": [
+      {
+        "href": "http://166.76.160.117:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+        "rel": "self",
+        "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87"
+      }
+    ]
+  }
+}`
+
+// GetNestedOutput represents the response body from a Get request on a
+// nested stack.
+const GetNestedOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "parent": "0b1771bd-9336-4f2b-ae86-a80f971faf1e",
+    "stack_user_project_id": "897686"
+  }
+}`
+
+// GetParametersOutput represents the response body from a Get request for a
+// stack with several parameters, including Heat pseudo parameters.
+const GetParametersOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "parameters": {
+      "flavor": "m1.tiny",
+      "image": "cirros-0.4.0",
+      "key_name": "heat_key",
+      "OS::stack_name": "postman_stack",
+      "OS::stack_id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+      "OS::project_id": "3b7ba5fbd9a24fd4be2a3a6bbd3b0f5c"
+    }
+  }
+}`
+
+// GetLargeOutputOutput represents the response body from a Get request for a
+// stack with an output too large to be represented exactly as a float64.
+const GetLargeOutputOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "outputs": [
+      {
+        "output_key": "volume_serial",
+        "output_value": 9007199254740993,
+        "description": "A 64-bit identifier"
+      }
+    ]
+  }
+}`
+
+// GetJoinedTagsOutput represents the response body from a Get request on a
+// Heat version returning tags as a comma-joined string.
+const GetJoinedTagsOutput = `
+{
+  "stack": {
+    "disable_rollback": true,
+    "description": "Simple template to test heat commands",
+    "parameters": {
+      "flavor": "m1.tiny",
+      "OS::stack_name": "postman_stack",
+      "OS::stack_id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87"
+    },
+    "stack_status_reason": "Stack CREATE completed successfully",
+    "stack_name": "postman_stack",
+    "outputs": [],
+    "creation_time": "2018-06-26T07:58:17Z",
+    "links": [
+    {
+      "href": "http://166.76.160.117:8004/v1/98606384f58d4ad0b3db7d0d779549ac/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+      "rel": "self"
+    }
+    ],
+    "capabilities": [],
+    "notification_topics": [],
+    "timeout_mins": null,
+    "stack_status": "CREATE_COMPLETE",
+    "updated_time": null,
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "template_description": "Simple template to test heat commands",
+	"tags": "rackspace,atx"
+  }
+}
+`
+
+// GetFailedOutputOutput represents the response body from a Get request of a
+// stack with an output that could not be resolved.
+const GetFailedOutputOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "outputs": [
+      {
+        "output_key": "server_ip",
+        "output_value": "10.0.0.5",
+        "description": "IP of the server"
+      },
+      {
+        "output_key": "vip",
+        "output_value": null,
+        "description": "Load balancer VIP",
+        "output_error": "The Referenced Attribute (lb vip_address) is incorrect."
+      }
+    ]
+  }
+}
+`
+
 // HandleGetSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
 // on the test handler mux that responds with a `Get` response.
 func HandleGetSuccessfully(t *testing.T, output string) {
@@ -223,6 +569,62 @@ func HandleGetSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// HandleGetSlowly creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
+// on the test handler mux that responds with GetOutput after the given delay.
+func HandleGetSlowly(t *testing.T, delay time.Duration) {
+	th.Mux.HandleFunc("/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, GetOutput)
+	})
+}
+
+// GetHiddenParameterOutput represents the response body from a Get request
+// for a stack with a hidden parameter.
+const GetHiddenParameterOutput = `
+{
+  "stack": {
+    "id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+    "stack_name": "postman_stack",
+    "stack_status": "CREATE_COMPLETE",
+    "parameters": {
+      "flavor": "m1.tiny",
+      "db_password": "******"
+    }
+  }
+}`
+
+// HandleGetForTenantSuccessfully creates an HTTP handler at
+// `/v1/<tenantID>/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
+// on the test handler mux that responds with a `Get` response.
+func HandleGetForTenantSuccessfully(t *testing.T, tenantID, output string) {
+	th.Mux.HandleFunc("/v1/"+tenantID+"/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, output)
+	})
+}
+
+// HandleGetWithOptsSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
+// on the test handler mux that checks the `resolve_outputs` query parameter.
+func HandleGetWithOptsSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestFormValues(t, r, map[string]string{"resolve_outputs": "true"})
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, output)
+	})
+}
+
 // HandleUpdateSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
 // on the test handler mux that responds with an `Update` response.
 func HandleUpdateSuccessfully(t *testing.T) {
@@ -236,37 +638,198 @@ func HandleUpdateSuccessfully(t *testing.T) {
 	})
 }
 
-// HandleUpdatePatchSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
-// on the test handler mux that responds with an `Update` response.
-func HandleUpdatePatchSuccessfully(t *testing.T) {
-	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
-		th.TestMethod(t, r, "PATCH")
+// UpdatePreviewOutput represents the response body from an UpdatePreview
+// request where a flavor change replaces the server.
+const UpdatePreviewOutput = `
+{
+  "resource_changes": {
+    "added": [],
+    "deleted": [],
+    "replaced": [
+      {
+        "resource_name": "server",
+        "resource_type": "OS::Nova::Server",
+        "logical_resource_id": "server"
+      }
+    ],
+    "unchanged": [
+      {
+        "resource_name": "port",
+        "resource_type": "OS::Neutron::Port",
+        "logical_resource_id": "port"
+      }
+    ],
+    "updated": [
+      {
+        "resource_name": "volume",
+        "resource_type": "OS::Cinder::Volume",
+        "logical_resource_id": "volume"
+      }
+    ]
+  }
+}`
+
+// HandleUpdatePreviewSuccessfully creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada/preview`
+// on the test handler mux that responds with an `UpdatePreview` response.
+func HandleUpdatePreviewSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada/preview", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "PUT")
 		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
 		th.TestHeader(t, r, "Accept", "application/json")
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusAccepted)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, output)
 	})
 }
 
-// HandleDeleteSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
-// on the test handler mux that responds with a `Delete` response.
-func HandleDeleteSuccessfully(t *testing.T) {
+// HandleUpdateWithHeaders creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada`
+// on the test handler mux that checks an `Update` request carries the given
+// headers.
+func HandleUpdateWithHeaders(t *testing.T, headers map[string]string) {
 	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
-		th.TestMethod(t, r, "DELETE")
+		th.TestMethod(t, r, "PUT")
 		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
-		th.TestHeader(t, r, "Accept", "application/json")
+		for k, v := range headers {
+			th.TestHeader(t, r, k, v)
+		}
 
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNoContent)
+		w.WriteHeader(http.StatusAccepted)
 	})
 }
 
-// GetExpected represents the expected object from a Get request.
-var PreviewExpected = &stacks.PreviewedStack{
-	DisableRollback: true,
-	Description:     "Simple template to test heat commands",
-	Parameters: map[string]string{
+// HandleUpdatePreservingTags creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada`
+// on the test handler mux that returns a stack tagged "web,prod" and checks
+// that an `Update` request sends the given body. The returned function
+// reports the number of Get requests handled.
+func HandleUpdatePreservingTags(t *testing.T, body string) func() int {
+	gets := 0
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			gets++
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{"stack": {"id": "db6977b2-27aa-4775-9ae7-6213212d4ada", "stack_name": "gophercloud-test-stack-2", "tags": ["web", "prod"]}}`)
+		case "PUT":
+			th.TestJSONRequest(t, r, body)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Fatalf("Unexpected method: [%s]", r.Method)
+		}
+	})
+	return func() int { return gets }
+}
+
+// HandleUpdateTagsSuccessfully creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada`
+// on the test handler mux that checks a tags-only `Update` request.
+func HandleUpdateTagsSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "PUT")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestJSONRequest(t, r, `{"existing": true, "tags": "deprecated,web"}`)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// GetWithPreviousTemplateOutput represents the response body from a Get
+// request of a stack that retains its previous template.
+const GetWithPreviousTemplateOutput = `
+{
+  "stack": {
+    "id": "db6977b2-27aa-4775-9ae7-6213212d4ada",
+    "stack_name": "gophercloud-test-stack-2",
+    "stack_status": "UPDATE_FAILED",
+    "previous_template": {
+      "heat_template_version": "2013-05-23",
+      "description": "Previous template"
+    }
+  }
+}`
+
+// GetBareWithPreviousTemplateOutput represents the response body from a Get
+// request of a stack that retains its previous template, from a Heat
+// deployment that does not wrap the stack in a `stack` key.
+const GetBareWithPreviousTemplateOutput = `
+{
+  "id": "db6977b2-27aa-4775-9ae7-6213212d4ada",
+  "stack_name": "gophercloud-test-stack-2",
+  "stack_status": "UPDATE_FAILED",
+  "previous_template": {
+    "heat_template_version": "2013-05-23",
+    "description": "Previous template"
+  }
+}`
+
+// GetWithoutPreviousTemplateOutput represents the response body from a Get
+// request of a stack without a previous template.
+const GetWithoutPreviousTemplateOutput = `
+{
+  "stack": {
+    "id": "db6977b2-27aa-4775-9ae7-6213212d4ada",
+    "stack_name": "gophercloud-test-stack-2",
+    "stack_status": "UPDATE_FAILED"
+  }
+}`
+
+// HandleRollbackToPrevious creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada`
+// on the test handler mux that responds to a Get with the given output and
+// accepts an Update re-applying the previous template.
+func HandleRollbackToPrevious(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, output)
+		case "PUT":
+			th.TestJSONRequest(t, r, `{"template": "{\"description\":\"Previous template\",\"heat_template_version\":\"2013-05-23\"}"}`)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Fatalf("Unexpected method: %s", r.Method)
+		}
+	})
+}
+
+// HandleUpdatePatchSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
+// on the test handler mux that responds with an `Update` response.
+func HandleUpdatePatchSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "PATCH")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// HandleDeleteSuccessfully creates an HTTP handler at `/stacks/postman_stack/16ef0584-4458-41eb-87c8-0dc8d5f66c87`
+// on the test handler mux that responds with a `Delete` response.
+func HandleDeleteSuccessfully(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "DELETE")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetExpected represents the expected object from a Get request.
+var PreviewExpected = &stacks.PreviewedStack{
+	DisableRollback: true,
+	Description:     "Simple template to test heat commands",
+	Parameters: map[string]string{
 		"flavor":         "m1.tiny",
 		"OS::stack_name": "postman_stack",
 		"OS::stack_id":   "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
@@ -299,6 +862,38 @@ func HandlePreviewSuccessfully(t *testing.T, output string) {
 	})
 }
 
+// PreviewConditionsOutput represents the response body from a Preview request
+// of a template using conditions. The resources of the nested stack are
+// reported as a nested list and, like older Heat versions, omit conditions.
+const PreviewConditionsOutput = `
+{
+  "stack": {
+    "id": "None",
+    "stack_name": "postman_stack",
+    "resources": [
+      {
+        "resource_name": "server",
+        "resource_type": "OS::Nova::Server",
+        "logical_resource_id": "server",
+        "condition": true
+      },
+      {
+        "resource_name": "volume",
+        "resource_type": "OS::Cinder::Volume",
+        "logical_resource_id": "volume",
+        "condition": false
+      },
+      [
+        {
+          "resource_name": "port",
+          "resource_type": "OS::Neutron::Port",
+          "logical_resource_id": "port"
+        }
+      ]
+    ]
+  }
+}`
+
 // AbandonExpected represents the expected object from an Abandon request.
 var AbandonExpected = &stacks.AbandonedStack{
 	Status: "COMPLETE",
@@ -328,17 +923,17 @@ var AbandonExpected = &stacks.AbandonedStack{
 	},
 	Action: "CREATE",
 	ID:     "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
-	Resources: map[string]interface{}{
-		"hello_world": map[string]interface{}{
-			"status":      "COMPLETE",
-			"name":        "hello_world",
-			"resource_id": "8a310d36-46fc-436f-8be4-37a696b8ac63",
-			"action":      "CREATE",
-			"type":        "OS::Nova::Server",
+	Resources: map[string]stacks.AbandonedResource{
+		"hello_world": {
+			Status:     "COMPLETE",
+			Name:       "hello_world",
+			ResourceID: "8a310d36-46fc-436f-8be4-37a696b8ac63",
+			Action:     "CREATE",
+			Type:       "OS::Nova::Server",
 		},
 	},
 	Files: map[string]string{
-		"file:///Users/prat8228/go/src/github.com/rackspace/rack/my_nova.yaml": "heat_template_version: 2014-10-16\nparameters:\n  flavor:\n    type: string\n    description: Flavor for the server to be created\n    default: 4353\n    hidden: true\nresources:\n  test_server:\n    type: \"OS::Nova::Server\"\n    properties:\n      name: test-server\n      flavor: 2 GB General Purpose v1\n image: Debian 7 (Wheezy) (PVHVM)\n",
+		"file:///Users/prat8228/go/src/github.com/rackspace/rack/my_nova.yaml": "heat_template_version: 2014-10-16\nparameters:\n  flavor:\n    type: string\n    description: Flavor for the server to be created\n    default: 4353\n    hidden: true\nresources:\n  test_server:\n    type: \"OS::Nova::Server\"\n    properties:\n      name: test-server\n      flavor: 2 GB General Purpose v1\n      image: Debian 7 (Wheezy) (PVHVM)\n",
 	},
 	StackUserProjectID: "897686",
 	ProjectID:          "897686",
@@ -353,6 +948,28 @@ var AbandonExpected = &stacks.AbandonedStack{
 	},
 }
 
+// AbandonDiffBefore and AbandonDiffAfter represent the abandon data of a stack
+// before and after a migration. hello_world was recreated, volume was dropped
+// and network was added.
+const (
+	AbandonDiffBefore = `
+{
+  "name": "postman_stack",
+  "resources": {
+    "hello_world": {"name": "hello_world", "status": "COMPLETE", "resource_id": "8a310d36-46fc-436f-8be4-37a696b8ac63", "type": "OS::Nova::Server"},
+    "volume": {"name": "volume", "status": "COMPLETE", "resource_id": "3b5f3a6c-2b1e-4d8e-9c0a-1f2e3d4c5b6a", "type": "OS::Cinder::Volume"}
+  }
+}`
+	AbandonDiffAfter = `
+{
+  "name": "postman_stack",
+  "resources": {
+    "hello_world": {"name": "hello_world", "status": "COMPLETE", "resource_id": "e4c8f0a2-7b3d-4c1e-8f9a-0b1c2d3e4f5a", "type": "OS::Nova::Server"},
+    "network": {"name": "network", "status": "COMPLETE", "resource_id": "0d9c8b7a-6f5e-4d3c-2b1a-0f9e8d7c6b5a", "type": "OS::Neutron::Net"}
+  }
+}`
+)
+
 // AbandonOutput represents the response body from an Abandon request.
 const AbandonOutput = `
 {
@@ -393,7 +1010,7 @@ const AbandonOutput = `
     }
   },
   "files": {
-    "file:///Users/prat8228/go/src/github.com/rackspace/rack/my_nova.yaml": "heat_template_version: 2014-10-16\nparameters:\n  flavor:\n    type: string\n    description: Flavor for the server to be created\n    default: 4353\n    hidden: true\nresources:\n  test_server:\n    type: \"OS::Nova::Server\"\n    properties:\n      name: test-server\n      flavor: 2 GB General Purpose v1\n image: Debian 7 (Wheezy) (PVHVM)\n"
+    "file:///Users/prat8228/go/src/github.com/rackspace/rack/my_nova.yaml": "heat_template_version: 2014-10-16\nparameters:\n  flavor:\n    type: string\n    description: Flavor for the server to be created\n    default: 4353\n    hidden: true\nresources:\n  test_server:\n    type: \"OS::Nova::Server\"\n    properties:\n      name: test-server\n      flavor: 2 GB General Purpose v1\n      image: Debian 7 (Wheezy) (PVHVM)\n"
 },
   "environment": {
 	"encrypted_param_names": [],
@@ -421,3 +1038,686 @@ func HandleAbandonSuccessfully(t *testing.T, output string) {
 		fmt.Fprintf(w, output)
 	})
 }
+
+// ActionInProgressOutput represents the response body of a 409 returned while
+// another action is in progress on the stack.
+const ActionInProgressOutput = `
+{
+  "explanation": "There was a conflict when trying to complete your request.",
+  "code": 409,
+  "error": {
+    "message": "Stack gophercloud-test-stack-2 already has an action (UPDATE) in progress.",
+    "traceback": null,
+    "type": "ActionInProgress"
+  },
+  "title": "Conflict"
+}`
+
+// ResourceConflictOutput represents the response body of a 409 unrelated to
+// an action in progress.
+const ResourceConflictOutput = `
+{
+  "explanation": "There was a conflict when trying to complete your request.",
+  "code": 409,
+  "error": {
+    "message": "The Stack (gophercloud-test-stack-2) already exists.",
+    "traceback": null,
+    "type": "StackExists"
+  },
+  "title": "Conflict"
+}`
+
+// HandleConflict creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada`
+// on the test handler mux that responds with a 409 Conflict.
+func HandleConflict(t *testing.T, output string) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, output)
+	})
+}
+
+// ValidateParametersOutput represents the response body from a Validate
+// request of a template with a required and a typed parameter.
+const ValidateParametersOutput = `
+{
+  "Description": "Template with parameters",
+  "Parameters": {
+    "key_name": {
+      "Type": "String",
+      "NoEcho": "false",
+      "Description": "",
+      "Label": "key_name"
+    },
+    "count": {
+      "Default": 1,
+      "Type": "Number",
+      "NoEcho": "false",
+      "Description": "",
+      "Label": "count"
+    },
+    "flavor": {
+      "Default": "m1.tiny",
+      "Type": "String",
+      "NoEcho": "false",
+      "Description": "",
+      "Label": "flavor"
+    }
+  }
+}`
+
+// HandleValidateSuccessfully creates an HTTP handler at `/validate`
+// on the test handler mux that responds with a `Validate` response.
+func HandleValidateSuccessfully(t *testing.T, output string) {
+	th.Mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, output)
+	})
+}
+
+// HandleActionSuccessfully creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada/actions`
+// on the test handler mux that expects the given action body.
+func HandleActionSuccessfully(t *testing.T, expectedBody string) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada/actions", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Accept", "application/json")
+		th.TestJSONRequest(t, r, expectedBody)
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// HandleGetStatusSequence creates an HTTP handler at `/stacks/{stackName}/{stackID}`
+// on the test handler mux that responds to successive `Get` requests with a
+// stack in each of the given statuses in turn, repeating the last one.
+func HandleGetStatusSequence(t *testing.T, stackName, stackID string, statuses ...string) {
+	calls := 0
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s", stackName, stackID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"stack": {"id": "%s", "stack_name": "%s", "stack_status": "%s", "stack_status_reason": "Stack %s"}}`,
+			stackID, stackName, status, strings.ToLower(status))
+	})
+}
+
+// HandleGetBodySequence creates an HTTP handler at `/stacks/{stackName}/{stackID}`
+// on the test handler mux that responds to successive `Get` requests with each
+// of the given stacks in turn, repeating the last one.
+func HandleGetBodySequence(t *testing.T, stackName, stackID string, stacks ...string) {
+	calls := 0
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s", stackName, stackID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		stack := stacks[len(stacks)-1]
+		if calls < len(stacks) {
+			stack = stacks[calls]
+		}
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"stack": %s}`, stack)
+	})
+}
+
+// HandleResourcesSequence creates an HTTP handler at `/stacks/{stackName}/{stackID}/resources`
+// on the test handler mux that lists total resources, of which the number in
+// a CREATE_COMPLETE status is taken in turn from completed on each request.
+// The last number is repeated once all have been used.
+func HandleResourcesSequence(t *testing.T, stackName, stackID string, total int, completed ...int) {
+	calls := 0
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s/resources", stackName, stackID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		complete := completed[len(completed)-1]
+		if calls < len(completed) {
+			complete = completed[calls]
+		}
+		calls++
+
+		resources := make([]map[string]string, total)
+		for i := range resources {
+			status := "CREATE_IN_PROGRESS"
+			if i < complete {
+				status = "CREATE_COMPLETE"
+			}
+			resources[i] = map[string]string{
+				"resource_name":   fmt.Sprintf("server_%d", i),
+				"resource_status": status,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"resources": resources})
+	})
+}
+
+// HandleNestedResources creates an HTTP handler at `/stacks/{stackName}/{stackID}/resources`
+// on the test handler mux that lists a nested stack resource and a server of
+// the stack, plus, when nested_depth is 1, two servers of the nested stack.
+func HandleNestedResources(t *testing.T, stackName, stackID string) {
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s/resources", stackName, stackID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		resources := []map[string]string{
+			{"resource_name": "group", "resource_status": "CREATE_IN_PROGRESS"},
+			{"resource_name": "server", "resource_status": "CREATE_COMPLETE"},
+		}
+		if r.URL.Query().Get("nested_depth") == "1" {
+			resources = append(resources,
+				map[string]string{"resource_name": "0", "resource_status": "CREATE_COMPLETE"},
+				map[string]string{"resource_name": "1", "resource_status": "CREATE_IN_PROGRESS"},
+			)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"resources": resources})
+	})
+}
+
+// HandleNestedStackTree creates HTTP handlers on the test handler mux listing
+// the resources of a stack hierarchy: the stack root/r1 nests root-group/n1
+// through its "group" resource, which nests root-group-0/n2. n1 also links
+// back to r1 to form a cycle.
+func HandleNestedStackTree(t *testing.T) {
+	resource := func(name, nested string) string {
+		links := `{"href": "http://heat/v1/tenant/stacks/x/y/resources/` + name + `", "rel": "self"}`
+		if nested != "" {
+			links += `, {"href": "http://heat/v1/tenant/stacks/` + nested + `", "rel": "nested"}`
+		}
+		return `{"resource_name": "` + name + `", "links": [` + links + `]}`
+	}
+	tree := map[string][]string{
+		"/stacks/root/r1/resources":         {resource("group", "root-group/n1"), resource("server", "")},
+		"/stacks/root-group/n1/resources":   {resource("0", "root-group-0/n2"), resource("parent", "root/r1")},
+		"/stacks/root-group-0/n2/resources": {},
+	}
+	for path, resources := range tree {
+		body := `{"resources": [` + strings.Join(resources, ", ") + `]}`
+		th.Mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "GET")
+			th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, body)
+		})
+	}
+}
+
+// HandleGetAllSuccessfully creates an HTTP handler at `/stacks/{stackName}/{stackID}`
+// for each of the given stacks on the test handler mux. Requests for any
+// other stack are answered with a 404 by the mux. The returned function
+// reports the highest number of requests that were handled at once.
+func HandleGetAllSuccessfully(t *testing.T, refs ...stacks.StackRef) func() int {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	for _, ref := range refs {
+		ref := ref
+		th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s", ref.Name, ref.ID), func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "GET")
+			th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{"stack": {"id": "%s", "stack_name": "%s", "stack_status": "CREATE_COMPLETE"}}`, ref.ID, ref.Name)
+		})
+	}
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return peak
+	}
+}
+
+// HandleDeleteBatchSuccessfully creates an HTTP handler at `/stacks/{stackName}/{stackID}`
+// for each of the given stacks on the test handler mux that responds with a
+// `Delete` response. Requests for any other stack are answered with a 404 by
+// the mux.
+func HandleDeleteBatchSuccessfully(t *testing.T, refs ...stacks.StackRef) {
+	for _, ref := range refs {
+		ref := ref
+		th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s", ref.Name, ref.ID), func(w http.ResponseWriter, r *http.Request) {
+			th.TestMethod(t, r, "DELETE")
+			th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// HandleDeleteConflict creates an HTTP handler at `/stacks/{stackName}/{stackID}`
+// on the test handler mux that refuses the deletion with a 409.
+func HandleDeleteConflict(t *testing.T, ref stacks.StackRef) {
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s", ref.Name, ref.ID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "DELETE")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.WriteHeader(http.StatusConflict)
+	})
+}
+
+// HandleGetRateLimited creates an HTTP handler at `/stacks/{stackName}/{stackID}`
+// on the test handler mux that answers the first request with a 429 carrying
+// the given Retry-After header, and the following ones with the stack in the
+// given status. The returned function reports the time of each request.
+func HandleGetRateLimited(t *testing.T, stackName, stackID, retryAfter, status string) func() []time.Time {
+	var mu sync.Mutex
+	var calls []time.Time
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s", stackName, stackID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		mu.Lock()
+		calls = append(calls, time.Now())
+		first := len(calls) == 1
+		mu.Unlock()
+
+		if first {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"stack": {"id": "%s", "stack_name": "%s", "stack_status": "%s"}}`, stackID, stackName, status)
+	})
+	return func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+// StackEventsOutput represents the response body from listing the events of
+// a stack whose creation failed.
+const StackEventsOutput = `
+{
+  "events": [
+    {
+      "resource_name": "hello_world",
+      "event_time": "2018-06-26T07:58:17Z",
+      "resource_status": "CREATE_IN_PROGRESS",
+      "id": "06feb26f-9298-4a9b-8749-9d770e5d577a"
+    },
+    {
+      "resource_name": "hello_world",
+      "event_time": "2018-06-26T07:59:17Z",
+      "resource_status": "CREATE_FAILED",
+      "resource_status_reason": "Quota exceeded",
+      "id": "93940999-7d40-44ae-8de4-19624e7b8d18"
+    }
+  ]
+}`
+
+// HandleStackEventsSuccessfully creates an HTTP handler at `/stacks/{stackName}/{stackID}/events`
+// on the test handler mux that responds with the given events.
+func HandleStackEventsSuccessfully(t *testing.T, stackName, stackID, output string) {
+	th.Mux.HandleFunc(fmt.Sprintf("/stacks/%s/%s/events", stackName, stackID), func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		if r.Form.Get("marker") != "" {
+			fmt.Fprintf(w, `{"events": []}`)
+			return
+		}
+		fmt.Fprintf(w, output)
+	})
+}
+
+// FailedStackEventsOutput represents the response body from listing the
+// events of a stack whose creation failed because of two of its resources,
+// newest first.
+const FailedStackEventsOutput = `
+{
+  "events": [
+    {
+      "resource_name": "stackcreated",
+      "event_time": "2018-06-26T07:59:10Z",
+      "resource_status": "CREATE_FAILED",
+      "resource_status_reason": "Resource CREATE failed: Conflict: resources.port: IP address already allocated",
+      "physical_resource_id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87",
+      "id": "c2a3b4d5-e6f7-4a8b-9c0d-1e2f3a4b5c6d"
+    },
+    {
+      "resource_name": "server",
+      "event_time": "2018-06-26T07:59:00Z",
+      "resource_status": "CREATE_FAILED",
+      "resource_status_reason": "CREATE aborted",
+      "id": "b1f2a3c4-d5e6-4f7a-8b9c-0d1e2f3a4b5c"
+    },
+    {
+      "resource_name": "port",
+      "event_time": "2018-06-26T07:58:30Z",
+      "resource_status": "CREATE_FAILED",
+      "resource_status_reason": "Conflict: IP address already allocated",
+      "id": "a0e1f2b3-c4d5-4e6f-9a7b-8c9d0e1f2a3b"
+    },
+    {
+      "resource_name": "port",
+      "event_time": "2018-06-26T07:58:17Z",
+      "resource_status": "CREATE_IN_PROGRESS",
+      "id": "9fd0e1a2-b3c4-4d5e-8f6a-7b8c9d0e1f2a"
+    }
+  ]
+}`
+
+// AuthenticatedTemplate represents a template served by a server requiring
+// authentication.
+const AuthenticatedTemplate = `{"heat_template_version": "2013-05-23", "description": "Authenticated template"}`
+
+// HandleAuthenticatedTemplate creates an HTTP handler at `/templates/stack.json`
+// on the test handler mux that only serves AuthenticatedTemplate to requests
+// carrying the given Authorization header.
+func HandleAuthenticatedTemplate(t *testing.T, authorization string) {
+	th.Mux.HandleFunc("/templates/stack.json", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, AuthenticatedTemplate)
+	})
+}
+
+// HandleTemplateHead creates an HTTP handler at `/templates/stack.json` on the
+// test handler mux that answers HEAD requests carrying the given Authorization
+// header.
+func HandleTemplateHead(t *testing.T, authorization string) {
+	th.Mux.HandleFunc("/templates/stack.json", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "HEAD")
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// HandleTemplateGetOnly creates an HTTP handler at `/templates/get-only.json`
+// on the test handler mux that rejects HEAD requests and serves
+// AuthenticatedTemplate to GET requests.
+func HandleTemplateGetOnly(t *testing.T) {
+	th.Mux.HandleFunc("/templates/get-only.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, AuthenticatedTemplate)
+	})
+}
+
+// HandleCreateInlinedTemplate creates an HTTP handler at `/stacks` on the test
+// handler mux that checks the request inlines the given template.
+func HandleCreateInlinedTemplate(t *testing.T, template string) {
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		var body struct {
+			Template string `json:"template"`
+		}
+		th.AssertNoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		th.AssertEquals(t, template, body.Template)
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, CreateOutput)
+	})
+}
+
+// HandleCreateRawBody creates an HTTP handler at `/stacks` on the test handler
+// mux that checks the request body is exactly the given one.
+func HandleCreateRawBody(t *testing.T, body string) {
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestJSONRequest(t, r, body)
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, CreateOutput)
+	})
+}
+
+// HandleCreateCompressed creates an HTTP handler at `/stacks` on the test
+// handler mux that checks the request body is gzip-compressed and decompresses
+// to the given one.
+func HandleCreateCompressed(t *testing.T, body string) {
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Content-Encoding", "gzip")
+		th.TestHeader(t, r, "Content-Type", "application/json")
+
+		gz, err := gzip.NewReader(r.Body)
+		th.AssertNoErr(t, err)
+		b, err := ioutil.ReadAll(gz)
+		th.AssertNoErr(t, err)
+		th.AssertJSONEquals(t, body, json.RawMessage(b))
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, CreateOutput)
+	})
+}
+
+// HandleCreateRejectingGzip creates an HTTP handler at `/stacks` on the test
+// handler mux that rejects gzip-compressed request bodies with a 415 and
+// accepts uncompressed ones, counting the requests in attempts.
+func HandleCreateRejectingGzip(t *testing.T, body string, attempts *int) {
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		*attempts++
+
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		th.TestJSONRequest(t, r, body)
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, CreateOutput)
+	})
+}
+
+// HandleUpdateCompressed creates an HTTP handler at
+// `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada` on
+// the test handler mux that checks the request body is gzip-compressed and
+// decompresses to the given one.
+func HandleUpdateCompressed(t *testing.T, body string) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "PUT")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestHeader(t, r, "Content-Encoding", "gzip")
+
+		gz, err := gzip.NewReader(r.Body)
+		th.AssertNoErr(t, err)
+		b, err := ioutil.ReadAll(gz)
+		th.AssertNoErr(t, err)
+		th.AssertJSONEquals(t, body, json.RawMessage(b))
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// HandleSwiftObjects creates HTTP handlers at `/swift/{container}/{object}`
+// on the test handler mux that store the uploaded objects and record the
+// deleted ones. Uploading failObject, if set, fails with a 500.
+func HandleSwiftObjects(t *testing.T, container, failObject string) (uploaded map[string]string, deleted *[]string) {
+	uploaded = make(map[string]string)
+	deleted = new([]string)
+	prefix := "/swift/" + container + "/"
+	th.Mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+
+		switch r.Method {
+		case "PUT":
+			if name == failObject {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded[name] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		case "DELETE":
+			*deleted = append(*deleted, name)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected method %s", r.Method)
+		}
+	})
+	return uploaded, deleted
+}
+
+// HandleSwiftDownload creates an HTTP handler at `/swift/{container}/{object}`
+// on the test handler mux that responds with the given content.
+func HandleSwiftDownload(t *testing.T, container, object, content string) {
+	th.Mux.HandleFunc("/swift/"+container+"/"+object, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		w.Header().Set("Content-Type", "application/x-yaml")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, content)
+	})
+}
+
+// HandleCreateFromContainer creates an HTTP handler at `/stacks` on the test
+// handler mux that expects the template of the stack to be given as the
+// templateURL and its files as the files_container, and responds with the
+// given status.
+func HandleCreateFromContainer(t *testing.T, container, templateURL string, status int) {
+	th.Mux.HandleFunc("/stacks", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		var body map[string]interface{}
+		th.AssertNoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		th.AssertEquals(t, container, body["files_container"])
+		th.AssertEquals(t, templateURL, body["template_url"])
+		if _, ok := body["files"]; ok {
+			t.Errorf("Expected no inlined files, got %v", body["files"])
+		}
+		if _, ok := body["template"]; ok {
+			t.Errorf("Expected no inlined template, got %v", body["template"])
+		}
+
+		w.WriteHeader(status)
+		if status == http.StatusCreated {
+			fmt.Fprintf(w, CreateOutput)
+		}
+	})
+}
+
+// HandleSnapshotSequence creates HTTP handlers at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada/snapshots`
+// on the test handler mux. Creating the snapshot responds with the first
+// status, and successive gets of the snapshot with the remaining ones.
+func HandleSnapshotSequence(t *testing.T, statuses ...string) {
+	base := "/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada/snapshots"
+	snapshot := `{"id": "a8a0b8bd-6e71-4f8e-8c3a-9a1b2c3d4e5f", "name": null, "status": "%s", "status_reason": "snapshot %s", "creation_time": "2018-06-26T07:58:17Z"}`
+
+	th.Mux.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+		th.TestJSONRequest(t, r, `{}`)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, snapshot, statuses[0], strings.ToLower(statuses[0]))
+	})
+
+	calls := 1
+	th.Mux.HandleFunc(base+"/a8a0b8bd-6e71-4f8e-8c3a-9a1b2c3d4e5f", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "X-Auth-Token", fake.TokenID)
+
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"snapshot": `+snapshot+`}`, status, strings.ToLower(status))
+	})
+}
+
+// HandleUpdateForbidden creates an HTTP handler at `/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada`
+// on the test handler mux that fails the test if the stack is updated.
+func HandleUpdateForbidden(t *testing.T) {
+	th.Mux.HandleFunc("/stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected %s request to update the stack", r.Method)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+}
+
+// AbandonedResourceOutput represents a resource of an abandoned stack with
+// resource data and fields that are not modeled by AbandonedResource.
+const AbandonedResourceOutput = `
+{
+  "name": "private_net",
+  "type": "OS::Neutron::Net",
+  "action": "CREATE",
+  "status": "COMPLETE",
+  "resource_id": "2d0d5c5f-7a3c-4b8e-9f1d-6c3b2a1e0f9d",
+  "resource_data": {"segmentation_id": "42"},
+  "metadata": {},
+  "propertiesData": {"name": "private"},
+  "version": "2016-10-14"
+}`
+
+// AbandonedResourceExpected represents the decoded AbandonedResourceOutput.
+var AbandonedResourceExpected = stacks.AbandonedResource{
+	Name:         "private_net",
+	Type:         "OS::Neutron::Net",
+	Action:       "CREATE",
+	Status:       "COMPLETE",
+	ResourceID:   "2d0d5c5f-7a3c-4b8e-9f1d-6c3b2a1e0f9d",
+	ResourceData: map[string]interface{}{"segmentation_id": "42"},
+	Metadata:     map[string]interface{}{},
+	Extra: map[string]interface{}{
+		"propertiesData": map[string]interface{}{"name": "private"},
+		"version":        "2016-10-14",
+	},
+}
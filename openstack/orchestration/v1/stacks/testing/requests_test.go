@@ -1,7 +1,16 @@
 package testing
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stacks"
@@ -39,6 +48,396 @@ func TestCreateStack(t *testing.T) {
 	th.AssertDeepEquals(t, expected, actual)
 }
 
+func TestCreateStackExtractID(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleCreateSuccessfully(t, CreateOutput)
+
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+	}
+	created, err := stacks.Create(fake.ServiceClient(), createOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "16ef0584-4458-41eb-87c8-0dc8d5f66c87", created.ID)
+	th.AssertEquals(t, 1, len(created.Links))
+	th.AssertEquals(t, "self", created.Links[0].Rel)
+}
+
+func TestFilesFromDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "stacks")
+	th.AssertNoErr(t, err)
+	defer os.RemoveAll(root)
+
+	th.AssertNoErr(t, os.MkdirAll(filepath.Join(root, "scripts"), 0755))
+	th.AssertNoErr(t, ioutil.WriteFile(filepath.Join(root, "scripts", "boot.sh"), []byte("#!/bin/sh\n"), 0644))
+	th.AssertNoErr(t, ioutil.WriteFile(filepath.Join(root, "logo.png"), []byte{0x89, 0x50, 0xff, 0xfe}, 0644))
+
+	files, err := stacks.FilesFromDir(root)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, map[string]interface{}{
+		"scripts/boot.sh": "#!/bin/sh\n",
+		"logo.png":        "iVD//g==",
+	}, files)
+
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+		Files:        files,
+	}
+	b, err := createOpts.ToStackCreateMap()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, map[string]string{
+		"scripts/boot.sh": "#!/bin/sh\n",
+		"logo.png":        "iVD//g==",
+	}, b["files"])
+}
+
+func TestCreateStackInvalidFiles(t *testing.T) {
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+		Files:        map[string]interface{}{"count.txt": 3},
+	}
+	_, err := createOpts.ToStackCreateMap()
+	if _, ok := err.(gophercloud.ErrInvalidInput); !ok {
+		t.Fatalf("expected an ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestCreateStackOmitsEmptySections(t *testing.T) {
+	for _, envBin := range []string{`{}`, ``, "# no sections\n"} {
+		createOpts := stacks.CreateOpts{
+			Name:            "stackcreated",
+			TemplateOpts:    &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+			EnvironmentOpts: &stacks.Environment{TE: stacks.TE{Bin: []byte(envBin)}},
+			Files:           map[string]interface{}{},
+			Parameters:      map[string]interface{}{},
+		}
+		b, err := createOpts.ToStackCreateMap()
+		th.AssertNoErr(t, err)
+		for _, key := range []string{"files", "parameters", "environment"} {
+			if _, ok := b[key]; ok {
+				t.Errorf("expected no %q in the request body for environment %q, got %v", key, envBin, b[key])
+			}
+		}
+	}
+}
+
+func TestCreateStackCompressed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleCreateCompressed(t, `{
+		"stack_name": "stackcreated",
+		"template": "{\"heat_template_version\":\"2013-05-23\"}",
+		"timeout_mins": 60
+	}`)
+
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		Timeout:      60,
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version":"2013-05-23"}`)}},
+		Compress:     true,
+	}
+	actual, err := stacks.Create(fake.ServiceClient(), createOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, CreateExpected, actual)
+}
+
+func TestCreateStackCompressedFallsBack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	attempts := 0
+	HandleCreateRejectingGzip(t, `{
+		"stack_name": "stackcreated",
+		"template": "{\"heat_template_version\":\"2013-05-23\"}",
+		"timeout_mins": 60
+	}`, &attempts)
+
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		Timeout:      60,
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version":"2013-05-23"}`)}},
+		Compress:     true,
+	}
+	actual, err := stacks.Create(fake.ServiceClient(), createOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, CreateExpected, actual)
+	th.AssertEquals(t, 2, attempts)
+}
+
+func TestUpdateStackCompressed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdateCompressed(t, `{"existing": true, "tags": "web"}`)
+
+	updateOpts := stacks.UpdateOpts{
+		Existing: true,
+		Tags:     []string{"web"},
+		Compress: true,
+	}
+	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestCreateStackExtraFields(t *testing.T) {
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+		Timeout:      60,
+		ExtraFields: map[string]interface{}{
+			"preview_only": true,
+			"stack_name":   "overridden",
+			"timeout_mins": 5,
+		},
+	}
+	b, err := createOpts.ToStackCreateMap()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, b["preview_only"])
+	th.AssertEquals(t, "stackcreated", b["stack_name"])
+	th.AssertEquals(t, float64(60), b["timeout_mins"])
+}
+
+func TestUpdateStackExtraFields(t *testing.T) {
+	updateOpts := stacks.UpdateOpts{
+		Existing: true,
+		Tags:     []string{"web"},
+		ExtraFields: map[string]interface{}{
+			"converge": true,
+			"tags":     "ignored",
+		},
+	}
+	b, err := updateOpts.ToStackUpdateMap()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, true, b["converge"])
+	th.AssertEquals(t, "web", b["tags"])
+}
+
+func TestCreateStackBodyTooLarge(t *testing.T) {
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+		Files:        map[string]interface{}{"big.txt": strings.Repeat("a", stacks.MaxBodySize)},
+	}
+
+	_, err := createOpts.ToStackCreateMap()
+	th.AssertNoErr(t, err)
+
+	stacks.CheckBodySize = true
+	defer func() { stacks.CheckBodySize = false }()
+	_, err = createOpts.ToStackCreateMap()
+	tooLarge, ok := err.(stacks.ErrBodyTooLarge)
+	if !ok {
+		t.Fatalf("expected an ErrBodyTooLarge, got %v", err)
+	}
+	th.AssertEquals(t, stacks.MaxBodySize, tooLarge.Limit)
+	if tooLarge.Size <= tooLarge.Limit {
+		t.Fatalf("expected a size above the limit, got %d", tooLarge.Size)
+	}
+}
+
+func swiftCreateOpts() stacks.CreateOpts {
+	return stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: []byte(`{"heat_template_version": "2013-05-23"}`)}},
+		Files: map[string]interface{}{
+			"scripts/boot.sh":  "#!/bin/sh",
+			"scripts/setup.sh": "#!/bin/sh",
+		},
+	}
+}
+
+func TestCreateViaSwift(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	uploaded, deleted := HandleSwiftObjects(t, "templates", "")
+	HandleCreateFromContainer(t, "templates", th.Endpoint()+"swift/templates/template.yaml", http.StatusCreated)
+
+	objectStore := fake.ServiceClient()
+	objectStore.Endpoint = th.Endpoint() + "swift/"
+	actual, err := stacks.CreateViaSwift(fake.ServiceClient(), objectStore, "templates", swiftCreateOpts()).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, CreateExpected, actual)
+	th.AssertDeepEquals(t, map[string]string{
+		"template.yaml":    `{"heat_template_version": "2013-05-23"}`,
+		"scripts/boot.sh":  "#!/bin/sh",
+		"scripts/setup.sh": "#!/bin/sh",
+	}, uploaded)
+	th.AssertEquals(t, 0, len(*deleted))
+}
+
+func TestFetchTemplateFromObjectStore(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	content := "heat_template_version: 2016-10-14\nresources: {}\n"
+	HandleSwiftDownload(t, "templates", "web.yaml", content)
+
+	objectStore := fake.ServiceClient()
+	objectStore.Endpoint = th.Endpoint() + "swift/"
+	bin, err := stacks.FetchTemplateFromObjectStore(objectStore, "templates", "web.yaml")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, content, string(bin))
+
+	createOpts := stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: &stacks.Template{TE: stacks.TE{Bin: bin}},
+	}
+	b, err := createOpts.ToStackCreateMap()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, content, b["template"])
+
+	_, err = stacks.FetchTemplateFromObjectStore(objectStore, "templates", "missing.yaml")
+	if _, ok := err.(gophercloud.ErrDefault404); !ok {
+		t.Fatalf("expected a 404 for a missing object, got %v", err)
+	}
+}
+
+func TestCreateViaSwiftCleansUp(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	_, deleted := HandleSwiftObjects(t, "templates", "")
+	HandleCreateFromContainer(t, "templates", th.Endpoint()+"swift/templates/template.yaml", http.StatusBadRequest)
+
+	objectStore := fake.ServiceClient()
+	objectStore.Endpoint = th.Endpoint() + "swift/"
+	err := stacks.CreateViaSwift(fake.ServiceClient(), objectStore, "templates", swiftCreateOpts()).Err
+	if err == nil {
+		t.Fatal("expected the create to fail")
+	}
+	th.AssertDeepEquals(t, []string{"template.yaml", "scripts/boot.sh", "scripts/setup.sh"}, *deleted)
+}
+
+func TestCreateViaSwiftUploadFailure(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	_, deleted := HandleSwiftObjects(t, "templates", "scripts/setup.sh")
+
+	objectStore := fake.ServiceClient()
+	objectStore.Endpoint = th.Endpoint() + "swift/"
+	err := stacks.CreateViaSwift(fake.ServiceClient(), objectStore, "templates", swiftCreateOpts()).Err
+	if err == nil {
+		t.Fatal("expected the upload to fail")
+	}
+	th.AssertDeepEquals(t, []string{"template.yaml", "scripts/boot.sh"}, *deleted)
+}
+
+func TestCreateStackTemplateURLHeaders(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleAuthenticatedTemplate(t, "Bearer template-token")
+	HandleCreateInlinedTemplate(t, AuthenticatedTemplate)
+
+	createOpts := stacks.CreateOpts{
+		Name:               "stackcreated",
+		TemplateOpts:       &stacks.Template{TE: stacks.TE{URL: th.Endpoint() + "templates/stack.json"}},
+		TemplateURLHeaders: map[string]string{"Authorization": "Bearer template-token"},
+	}
+	actual, err := stacks.Create(fake.ServiceClient(), createOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, CreateExpected, actual)
+}
+
+func TestCreateStackTemplateURLHeadersRejected(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleAuthenticatedTemplate(t, "Bearer template-token")
+
+	createOpts := stacks.CreateOpts{
+		Name:               "stackcreated",
+		TemplateOpts:       &stacks.Template{TE: stacks.TE{URL: th.Endpoint() + "templates/stack.json"}},
+		TemplateURLHeaders: map[string]string{"Authorization": "Bearer wrong-token"},
+	}
+	_, err := stacks.Create(fake.ServiceClient(), createOpts).Extract()
+	if err == nil {
+		t.Fatal("expected the template fetch to be rejected")
+	}
+}
+
+func TestCheckTemplateURL(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTemplateHead(t, "Bearer template-token")
+	HandleTemplateGetOnly(t)
+
+	url := th.Endpoint() + "templates/stack.json"
+	createOpts := stacks.CreateOpts{
+		Name:               "stackcreated",
+		TemplateOpts:       &stacks.Template{TE: stacks.TE{URL: url}},
+		TemplateURLHeaders: map[string]string{"Authorization": "Bearer template-token"},
+	}
+	th.AssertNoErr(t, createOpts.CheckTemplateURL(nil))
+	th.AssertEquals(t, url, createOpts.TemplateOpts.URL)
+	if createOpts.TemplateOpts.Bin != nil {
+		t.Fatal("expected the template not to be fetched into the opts")
+	}
+
+	createOpts.TemplateURLHeaders = map[string]string{"Authorization": "Bearer wrong-token"}
+	err := createOpts.CheckTemplateURL(nil)
+	if e, ok := err.(stacks.ErrTemplateURLUnreachable); !ok || e.Status != "401 Unauthorized" {
+		t.Fatalf("expected an ErrTemplateURLUnreachable with status 401, got %v", err)
+	}
+
+	createOpts.TemplateOpts = &stacks.Template{TE: stacks.TE{URL: th.Endpoint() + "templates/get-only.json"}}
+	th.AssertNoErr(t, createOpts.CheckTemplateURL(http.DefaultClient))
+
+	createOpts.TemplateOpts = &stacks.Template{TE: stacks.TE{URL: th.Endpoint() + "templates/missing.json"}}
+	err = createOpts.CheckTemplateURL(nil)
+	if e, ok := err.(stacks.ErrTemplateURLUnreachable); !ok || e.Status != "404 Not Found" {
+		t.Fatalf("expected an ErrTemplateURLUnreachable with status 404, got %v", err)
+	}
+}
+
+func TestCreateStackRawBody(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleCreateRawBody(t, `{
+		"stack_name": "stackcreated",
+		"template_url": "https://example.com/templates/stack.yaml",
+		"converge": true
+	}`)
+
+	createOpts := stacks.CreateOpts{
+		// Ignored in favor of RawBody.
+		Timeout: 60,
+		RawBody: map[string]interface{}{
+			"stack_name":   "stackcreated",
+			"template_url": "https://example.com/templates/stack.yaml",
+			"converge":     true,
+		},
+	}
+	actual, err := stacks.Create(fake.ServiceClient(), createOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, CreateExpected, actual)
+}
+
+func TestCreateStackID(t *testing.T) {
+	newOpts := func() stacks.CreateOpts {
+		template := new(stacks.Template)
+		template.Bin = []byte(`{"heat_template_version": "2013-05-23"}`)
+		return stacks.CreateOpts{Name: "stackcreated", TemplateOpts: template}
+	}
+
+	t.Run("location", func(t *testing.T) {
+		th.SetupHTTP()
+		defer th.TeardownHTTP()
+		HandleCreateWithLocation(t, `{"stack": {}}`)
+
+		id, err := stacks.Create(fake.ServiceClient(), newOpts()).StackID()
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, "d3c07a8e-5a5b-4f3f-9a35-7ce3c7b0e1d2", id)
+	})
+
+	t.Run("body", func(t *testing.T) {
+		th.SetupHTTP()
+		defer th.TeardownHTTP()
+		HandleCreateSuccessfully(t, CreateOutput)
+
+		id, err := stacks.Create(fake.ServiceClient(), newOpts()).StackID()
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, "16ef0584-4458-41eb-87c8-0dc8d5f66c87", id)
+	})
+}
+
 func TestCreateStackMissingRequiredInOpts(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
@@ -127,121 +526,1117 @@ func TestListStack(t *testing.T) {
 	th.CheckEquals(t, count, 1)
 }
 
-func TestGetStack(t *testing.T) {
+func TestListStackDetail(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandleGetSuccessfully(t, GetOutput)
+	HandleListDetailSuccessfully(t, ListDetailOutput)
 
-	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
-	th.AssertNoErr(t, err)
+	count := 0
+	err := stacks.ListDetail(fake.ServiceClient(), nil).EachPage(func(page pagination.Page) (bool, error) {
+		count++
+		actual, err := stacks.ExtractStacksDetail(page)
+		th.AssertNoErr(t, err)
 
-	expected := GetExpected
-	th.AssertDeepEquals(t, expected, actual)
+		th.CheckDeepEquals(t, ListDetailExpected, actual)
+
+		return true, nil
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, count, 1)
 }
 
-func TestUpdateStack(t *testing.T) {
+func TestExtractAllDetail(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandleUpdateSuccessfully(t)
+	HandleListDetailSuccessfully(t, ListDetailOutput)
 
-	template := new(stacks.Template)
-	template.Bin = []byte(`
-		{
-			"heat_template_version": "2013-05-23",
-			"description": "Simple template to test heat commands",
-			"parameters": {
-				"flavor": {
-					"default": "m1.tiny",
-					"type": "string"
-				}
-			}
-		}`)
-	updateOpts := &stacks.UpdateOpts{
-		TemplateOpts: template,
-	}
-	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	actual, err := stacks.ExtractAllDetail(fake.ServiceClient(), nil, false)
 	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, ListDetailExpected, actual)
 }
 
-func TestUpdateStackNoTemplate(t *testing.T) {
+func TestExtractAllDetailFallback(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandleUpdateSuccessfully(t)
-
-	parameters := make(map[string]interface{})
-	parameters["flavor"] = "m1.tiny"
+	HandleListSuccessfully(t, FullListOutput)
+	HandleGetAllSuccessfully(t,
+		stacks.StackRef{Name: "postman_stack", ID: "16ef0584-4458-41eb-87c8-0dc8d5f66c87"},
+		stacks.StackRef{Name: "gophercloud-test-stack-2", ID: "db6977b2-27aa-4775-9ae7-6213212d4ada"},
+	)
 
-	updateOpts := &stacks.UpdateOpts{
-		Parameters: parameters,
+	_, err := stacks.ExtractAllDetail(fake.ServiceClient(), nil, false)
+	if _, ok := err.(gophercloud.ErrDefault404); !ok {
+		t.Fatalf("expected a 404 without fallback, got %v", err)
 	}
-	expected := stacks.ErrTemplateRequired{}
 
-	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
-	th.AssertEquals(t, expected, err)
+	actual, err := stacks.ExtractAllDetail(fake.ServiceClient(), nil, true)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, len(actual))
+	th.AssertEquals(t, "postman_stack", actual[0].Name)
+	th.AssertEquals(t, "db6977b2-27aa-4775-9ae7-6213212d4ada", actual[1].ID)
+	th.AssertEquals(t, "CREATE_COMPLETE", actual[1].Status)
 }
 
-func TestUpdatePatchStack(t *testing.T) {
+func TestListUpdatedSince(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandleUpdatePatchSuccessfully(t)
+	// Heat sorts stacks that were never updated last; this one was created
+	// after all the others.
+	never := fmt.Sprintf(`{"id": "stack-new", "stack_name": "stack-new", "creation_time": "%s"}`,
+		ListUpdatedSinceNewest.Add(time.Hour).Format(time.RFC3339))
+	requests := HandleListUpdatedSince(t, append(ListUpdatedSinceStacks(250), never))
 
-	parameters := make(map[string]interface{})
-	parameters["flavor"] = "m1.tiny"
-
-	updateOpts := &stacks.UpdateOpts{
-		Parameters: parameters,
-	}
-	err := stacks.UpdatePatch(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	since := ListUpdatedSinceNewest.Add(-149*time.Hour - 30*time.Minute)
+	actual, err := stacks.ListUpdatedSince(fake.ServiceClient(), since)
 	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 151, len(actual))
+	th.AssertEquals(t, "stack-new", actual[0].Name)
+	th.AssertEquals(t, "stack-0", actual[1].Name)
+	th.AssertEquals(t, "stack-149", actual[150].Name)
+	th.AssertEquals(t, 4, *requests)
 }
 
-func TestDeleteStack(t *testing.T) {
+func TestListUpdatedSinceAll(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandleDeleteSuccessfully(t)
+	requests := HandleListUpdatedSince(t, ListUpdatedSinceStacks(200))
 
-	err := stacks.Delete(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	since := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	actual, err := stacks.ListUpdatedSince(fake.ServiceClient(), since)
 	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 200, len(actual))
+	th.AssertEquals(t, 3, *requests)
 }
 
-func TestPreviewStack(t *testing.T) {
+func TestGetStack(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandlePreviewSuccessfully(t, GetOutput)
+	HandleGetSuccessfully(t, GetOutput)
 
-	template := new(stacks.Template)
-	template.Bin = []byte(`
-		{
-			"heat_template_version": "2013-05-23",
-			"description": "Simple template to test heat commands",
-			"parameters": {
-				"flavor": {
-					"default": "m1.tiny",
-					"type": "string"
-				}
-			}
-		}`)
-	previewOpts := stacks.PreviewOpts{
-		Name:            "stackcreated",
-		Timeout:         60,
-		TemplateOpts:    template,
-		DisableRollback: gophercloud.Disabled,
-	}
-	actual, err := stacks.Preview(fake.ServiceClient(), previewOpts).Extract()
+	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
 	th.AssertNoErr(t, err)
 
-	expected := PreviewExpected
+	expected := GetExpected
 	th.AssertDeepEquals(t, expected, actual)
 }
 
-func TestAbandonStack(t *testing.T) {
+func TestGetStackUpdatedTime(t *testing.T) {
+	for updatedTime, expected := range map[string]*time.Time{
+		`null`:                   nil,
+		`""`:                     nil,
+		`"2018-06-26T07:59:17Z"`: &Updated_time,
+	} {
+		t.Run(updatedTime, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+			HandleGetSuccessfully(t, strings.Replace(GetOutput, `"updated_time": null`, `"updated_time": `+updatedTime, 1))
+
+			actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+			th.AssertNoErr(t, err)
+			th.AssertDeepEquals(t, expected, actual.UpdatedTime)
+		})
+	}
+}
+
+func TestGetNestedStack(t *testing.T) {
 	th.SetupHTTP()
 	defer th.TeardownHTTP()
-	HandleAbandonSuccessfully(t, AbandonOutput)
+	HandleGetSuccessfully(t, GetNestedOutput)
 
-	actual, err := stacks.Abandon(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c8").Extract()
+	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
 	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "0b1771bd-9336-4f2b-ae86-a80f971faf1e", actual.Parent)
+	th.AssertEquals(t, "897686", actual.StackUserProjectID)
+}
 
-	expected := AbandonExpected
-	th.AssertDeepEquals(t, expected, actual)
+func TestGetForTenant(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetForTenantSuccessfully(t, "e0d6c1b5c71d4f2f8d5e2b0f1a7b3c9d", GetOutput)
+
+	c := fake.ServiceClient()
+	c.Endpoint = th.Endpoint() + "v1/98606384f58d4ad0b3db7d0d779549ac/"
+	actual, err := stacks.GetForTenant(c, "e0d6c1b5c71d4f2f8d5e2b0f1a7b3c9d", "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, GetExpected, actual)
+}
+
+func TestStackActionAndState(t *testing.T) {
+	stack := stacks.RetrievedStack{Status: stacks.StatusUpdateFailed}
+	th.AssertEquals(t, "UPDATE", stack.Action())
+	th.AssertEquals(t, "FAILED", stack.State())
+
+	action, state := stacks.SplitStatus(stacks.StatusRollbackInProgress)
+	th.AssertEquals(t, "ROLLBACK", action)
+	th.AssertEquals(t, "IN_PROGRESS", state)
+
+	listed := stacks.ListedStack{Status: stacks.StatusCreateComplete}
+	th.AssertEquals(t, "CREATE", listed.Action())
+	th.AssertEquals(t, "COMPLETE", listed.State())
+}
+
+func TestListOptsStatuses(t *testing.T) {
+	query, err := stacks.ListOpts{
+		Statuses: []string{stacks.StatusCreateComplete, stacks.StatusUpdateComplete},
+	}.ToStackListQuery()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "?status=CREATE_COMPLETE&status=UPDATE_COMPLETE", query)
+
+	query, err = stacks.ListOpts{Status: stacks.StatusCreateComplete}.ToStackListQuery()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "?status=CREATE_COMPLETE", query)
+}
+
+func TestEndpointNotSet(t *testing.T) {
+	c := fake.ServiceClient()
+	c.Endpoint = ""
+
+	_, err := stacks.Get(c, "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	if _, ok := err.(stacks.ErrEndpointNotSet); !ok {
+		t.Fatalf("expected an ErrEndpointNotSet, got %v", err)
+	}
+
+	err = stacks.Delete(c, "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").ExtractErr()
+	if _, ok := err.(stacks.ErrEndpointNotSet); !ok {
+		t.Fatalf("expected an ErrEndpointNotSet, got %v", err)
+	}
+
+	_, err = stacks.List(c, nil).AllPages()
+	if _, ok := err.(stacks.ErrEndpointNotSet); !ok {
+		t.Fatalf("expected an ErrEndpointNotSet, got %v", err)
+	}
+}
+
+func TestNestedStackIDs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleNestedStackTree(t)
+
+	refs, err := stacks.NestedStackIDs(fake.ServiceClient(), "root", "r1")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []stacks.StackRef{
+		{Name: "root-group", ID: "n1"},
+		{Name: "root-group-0", ID: "n2"},
+	}, refs)
+}
+
+func TestDiffAbandonData(t *testing.T) {
+	var before, after map[string]interface{}
+	th.AssertNoErr(t, json.Unmarshal([]byte(AbandonDiffBefore), &before))
+	th.AssertNoErr(t, json.Unmarshal([]byte(AbandonDiffAfter), &after))
+
+	diff, err := stacks.DiffAbandonData(before, after)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, stacks.AbandonDiff{
+		Added:   []string{"network"},
+		Removed: []string{"volume"},
+		Changed: []string{"hello_world"},
+	}, diff)
+
+	diff, err = stacks.DiffAbandonData(before, before)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, stacks.AbandonDiff{}, diff)
+
+	_, err = stacks.DiffAbandonData(before, map[string]interface{}{"resources": "none"})
+	if err == nil {
+		t.Fatal("expected an error for malformed resources")
+	}
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSlowly(t, 200*time.Millisecond)
+	stacks.SetDefaultTimeout(20 * time.Millisecond)
+	defer stacks.SetDefaultTimeout(0)
+
+	_, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	if e, ok := err.(net.Error); !ok || !e.Timeout() {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+
+	// The timeout of the client takes precedence.
+	client := fake.ServiceClient()
+	client.RequestTimeout = 5 * time.Second
+	_, err = stacks.Get(client, "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	th.AssertNoErr(t, err)
+}
+
+func TestGetStackExtractRaw(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetOutput)
+
+	res := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87")
+
+	raw, err := res.ExtractRaw()
+	th.AssertNoErr(t, err)
+	stack, ok := raw["stack"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a stack object in the raw body, got %v", raw)
+	}
+	th.AssertEquals(t, "postman_stack", stack["stack_name"])
+	th.AssertEquals(t, "Stack CREATE completed successfully", stack["stack_status_reason"])
+
+	actual, err := res.Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, GetExpected, actual)
+}
+
+func TestGetStackLargeNumbers(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetLargeOutputOutput)
+
+	raw, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").ExtractRaw()
+	th.AssertNoErr(t, err)
+
+	stack := raw["stack"].(map[string]interface{})
+	output := stack["outputs"].([]interface{})[0].(map[string]interface{})
+	th.AssertEquals(t, json.Number("9007199254740993"), output["output_value"])
+}
+
+func TestGetStackOutputIntLarge(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetLargeOutputOutput)
+
+	stack, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	th.AssertNoErr(t, err)
+	serial, err := stack.OutputInt("volume_serial")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 9007199254740993, serial)
+}
+
+func TestGetStackEnvelopes(t *testing.T) {
+	outputs := map[string]string{
+		"enveloped": GetOutput,
+		"bare":      GetBareOutput,
+	}
+	for name, output := range outputs {
+		t.Run(name, func(t *testing.T) {
+			th.SetupHTTP()
+			defer th.TeardownHTTP()
+			HandleGetSuccessfully(t, output)
+
+			actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+			th.AssertNoErr(t, err)
+			th.AssertDeepEquals(t, GetExpected, actual)
+		})
+	}
+}
+
+func TestGetStackStrictDecoding(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetExtraFieldsOutput)
+
+	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "postman_stack", actual.Name)
+
+	_, err = stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").ExtractStrict()
+	unknown, ok := err.(stacks.ErrUnknownFields)
+	if !ok {
+		t.Fatalf("expected ErrUnknownFields, got %#v", err)
+	}
+	th.AssertEquals(t, "RetrievedStack", unknown.Type)
+	th.AssertDeepEquals(t, []string{"deletion_time", "stack_owner", "type"}, unknown.Fields)
+}
+
+func TestGetStackStrictDecodingNested(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetExtraLinkFieldsOutput)
+
+	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").ExtractStrict()
+	unknown, ok := err.(stacks.ErrUnknownFields)
+	if !ok {
+		t.Fatalf("expected ErrUnknownFields, got %#v", err)
+	}
+	th.AssertEquals(t, "16ef0584-4458-41eb-87c8-0dc8d5f66c87", actual.ID)
+	th.AssertEquals(t, 1, len(unknown.Fields))
+	th.AssertEquals(t, "id", unknown.Fields[0])
+}
+
+func TestGetStackStrictDecodingModeled(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetOutput)
+
+	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").ExtractStrict()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, GetExpected, actual)
+}
+
+func TestGetStackJoinedTags(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetJoinedTagsOutput)
+
+	actual, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	th.AssertNoErr(t, err)
+
+	expected := GetExpected
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestGetStackWithOpts(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetWithOptsSuccessfully(t, GetHiddenParameterOutput)
+
+	resolve := true
+	opts := stacks.GetOpts{WithResolvedParameters: &resolve}
+	actual, err := stacks.GetWithOpts(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87", opts).Extract()
+	th.AssertNoErr(t, err)
+
+	th.AssertEquals(t, "m1.tiny", actual.Parameters["flavor"])
+	th.AssertEquals(t, "******", actual.Parameters["db_password"])
+}
+
+func TestUpdateStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdateSuccessfully(t)
+
+	template := new(stacks.Template)
+	template.Bin = []byte(`
+		{
+			"heat_template_version": "2013-05-23",
+			"description": "Simple template to test heat commands",
+			"parameters": {
+				"flavor": {
+					"default": "m1.tiny",
+					"type": "string"
+				}
+			}
+		}`)
+	updateOpts := &stacks.UpdateOpts{
+		TemplateOpts: template,
+	}
+	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestUpdateStackPreserveTags(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	gets := HandleUpdatePreservingTags(t, `{"existing": true, "tags": "web,prod"}`)
+
+	updateOpts := stacks.UpdateOpts{
+		Existing:     true,
+		PreserveTags: true,
+	}
+	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, gets())
+}
+
+func TestUpdateStackPreserveTagsExplicit(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	gets := HandleUpdatePreservingTags(t, `{"existing": true, "tags": "staging"}`)
+
+	updateOpts := &stacks.UpdateOpts{
+		Existing:     true,
+		Tags:         []string{"staging"},
+		PreserveTags: true,
+	}
+	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, gets())
+}
+
+func TestUpdatePreview(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdatePreviewSuccessfully(t, UpdatePreviewOutput)
+
+	template := new(stacks.Template)
+	template.Bin = []byte(`heat_template_version: 2013-05-23`)
+	updateOpts := &stacks.UpdateOpts{
+		TemplateOpts: template,
+	}
+	changes, err := stacks.UpdatePreview(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 0, len(changes.Added))
+	th.AssertEquals(t, 1, len(changes.Replaced))
+	th.AssertEquals(t, "OS::Nova::Server", changes.Replaced[0].Type)
+	th.AssertEquals(t, "port", changes.Unchanged[0].Name)
+	th.AssertEquals(t, "volume", changes.Updated[0].Name)
+}
+
+func TestPredictReplacements(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdatePreviewSuccessfully(t, UpdatePreviewOutput)
+
+	template := new(stacks.Template)
+	template.Bin = []byte(`heat_template_version: 2013-05-23`)
+	updateOpts := &stacks.UpdateOpts{
+		TemplateOpts: template,
+	}
+	replaced, err := stacks.PredictReplacements(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{"server"}, replaced)
+}
+
+func TestPredictReplacementsInPlace(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdatePreviewSuccessfully(t, `{"resource_changes": {"replaced": [], "updated": [{"resource_name": "server"}]}}`)
+
+	template := new(stacks.Template)
+	template.Bin = []byte(`heat_template_version: 2013-05-23`)
+	updateOpts := &stacks.UpdateOpts{
+		TemplateOpts: template,
+	}
+	replaced, err := stacks.PredictReplacements(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{}, replaced)
+}
+
+func TestUpdateStackNoTemplate(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdateSuccessfully(t)
+
+	parameters := make(map[string]interface{})
+	parameters["flavor"] = "m1.tiny"
+
+	updateOpts := &stacks.UpdateOpts{
+		Parameters: parameters,
+	}
+	expected := stacks.ErrTemplateRequired{}
+
+	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertEquals(t, expected, err)
+}
+
+func TestUpdateStackMoreHeaders(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdateWithHeaders(t, map[string]string{
+		"X-Trace-Id":  "3f2b1c0d",
+		"X-Audit-For": "deploy-bot",
+	})
+
+	client := fake.ServiceClient()
+	client.MoreHeaders = map[string]string{
+		"X-Trace-Id":  "client-wide",
+		"X-Audit-For": "deploy-bot",
+	}
+	updateOpts := stacks.UpdateOpts{
+		Existing:    true,
+		MoreHeaders: map[string]string{"X-Trace-Id": "3f2b1c0d"},
+	}
+	err := stacks.Update(client, "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+	// The headers of the client must not be added to the map of the caller.
+	th.AssertDeepEquals(t, map[string]string{"X-Trace-Id": "3f2b1c0d"}, updateOpts.MoreHeaders)
+}
+
+func TestUpdateStackTagsOnly(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdateTagsSuccessfully(t)
+
+	updateOpts := &stacks.UpdateOpts{
+		Tags:     []string{"deprecated", "web"},
+		Existing: true,
+	}
+	err := stacks.Update(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestUpdatePatchStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleUpdatePatchSuccessfully(t)
+
+	parameters := make(map[string]interface{})
+	parameters["flavor"] = "m1.tiny"
+
+	updateOpts := &stacks.UpdateOpts{
+		Parameters: parameters,
+	}
+	err := stacks.UpdatePatch(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestDeleteStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleDeleteSuccessfully(t)
+
+	err := stacks.Delete(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestDeleteIfExists(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleDeleteSuccessfully(t)
+
+	err := stacks.DeleteIfExists(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestDeleteIfExistsAlreadyGone(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	err := stacks.DeleteIfExists(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestDeleteIfExistsConflict(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleConflict(t, ActionInProgressOutput)
+
+	err := stacks.DeleteIfExists(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	_, ok := err.(stacks.ErrActionInProgress)
+	th.AssertEquals(t, true, ok)
+}
+
+func TestCreateStackFilesContainer(t *testing.T) {
+	template := new(stacks.Template)
+	template.Bin = []byte(`heat_template_version: 2013-05-23`)
+	template.Files = map[string]string{"my_nova.yaml": "heat_template_version: 2014-10-16"}
+
+	createOpts := stacks.CreateOpts{
+		Name:           "stackcreated",
+		TemplateOpts:   template,
+		FilesContainer: "heat-files",
+	}
+	b, err := createOpts.ToStackCreateMap()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "heat-files", b["files_container"])
+	th.AssertDeepEquals(t, map[string]string{"my_nova.yaml": "heat_template_version: 2014-10-16"}, b["files"])
+
+	updateOpts := stacks.UpdateOpts{
+		Existing:       true,
+		FilesContainer: "heat-files",
+	}
+	b, err = updateOpts.ToStackUpdateMap()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "heat-files", b["files_container"])
+	if _, ok := b["files"]; ok {
+		t.Fatalf("expected no inlined files, got %v", b["files"])
+	}
+}
+
+func TestStackMapsShareCommonFields(t *testing.T) {
+	newTemplate := func() *stacks.Template {
+		template := new(stacks.Template)
+		template.Bin = []byte(`{"heat_template_version": "2013-05-23"}`)
+		template.Files = map[string]string{"my_nova.yaml": "heat_template_version: 2014-10-16"}
+		return template
+	}
+	newEnvironment := func() *stacks.Environment {
+		environment := new(stacks.Environment)
+		environment.Bin = []byte(`{"parameters": {"flavor": "m1.tiny"}}`)
+		return environment
+	}
+	disableRollback := true
+	parameters := map[string]interface{}{"flavor": "m1.small"}
+	tags := []string{"web", "prod"}
+
+	create, err := stacks.CreateOpts{
+		Name:            "stack",
+		TemplateOpts:    newTemplate(),
+		EnvironmentOpts: newEnvironment(),
+		DisableRollback: &disableRollback,
+		Parameters:      parameters,
+		Timeout:         30,
+		Tags:            tags,
+	}.ToStackCreateMap()
+	th.AssertNoErr(t, err)
+
+	update, err := stacks.UpdateOpts{
+		TemplateOpts:    newTemplate(),
+		EnvironmentOpts: newEnvironment(),
+		Parameters:      parameters,
+		Timeout:         30,
+		Tags:            tags,
+	}.ToStackUpdateMap()
+	th.AssertNoErr(t, err)
+
+	preview, err := stacks.PreviewOpts{
+		Name:            "stack",
+		TemplateOpts:    newTemplate(),
+		EnvironmentOpts: newEnvironment(),
+		DisableRollback: &disableRollback,
+		Parameters:      parameters,
+		Timeout:         30,
+	}.ToStackPreviewMap()
+	th.AssertNoErr(t, err)
+
+	adopt, err := stacks.AdoptOpts{
+		AdoptStackData:  "{}",
+		Name:            "stack",
+		TemplateOpts:    newTemplate(),
+		EnvironmentOpts: newEnvironment(),
+		DisableRollback: &disableRollback,
+		Parameters:      parameters,
+		Timeout:         30,
+	}.ToStackAdoptMap()
+	th.AssertNoErr(t, err)
+
+	shared := map[string][]string{
+		"update":  {"template", "environment", "files", "parameters", "timeout_mins", "tags"},
+		"preview": {"stack_name", "template", "environment", "files", "parameters", "timeout_mins", "disable_rollback"},
+		"adopt":   {"stack_name", "template", "environment", "files", "parameters", "timeout_mins", "disable_rollback"},
+	}
+	maps := map[string]map[string]interface{}{"update": update, "preview": preview, "adopt": adopt}
+	for name, keys := range shared {
+		for _, key := range keys {
+			if _, ok := create[key]; !ok {
+				t.Fatalf("create body is missing %s", key)
+			}
+			th.AssertDeepEquals(t, create[key], maps[name][key])
+		}
+	}
+}
+
+func TestPreviewOptsToCreateOpts(t *testing.T) {
+	template := new(stacks.Template)
+	template.Bin = []byte(`{"heat_template_version": "2013-05-23"}`)
+	template.Files = map[string]string{"my_nova.yaml": "heat_template_version: 2014-10-16"}
+	previewOpts := stacks.PreviewOpts{
+		Name:            "stackcreated",
+		Timeout:         60,
+		TemplateOpts:    template,
+		DisableRollback: gophercloud.Disabled,
+		Parameters: map[string]interface{}{
+			"flavor":   "m1.tiny",
+			"networks": []interface{}{"private"},
+		},
+	}
+
+	createOpts := previewOpts.ToCreateOpts()
+	th.AssertEquals(t, "stackcreated", createOpts.Name)
+	th.AssertEquals(t, 60, createOpts.Timeout)
+	th.AssertEquals(t, false, *createOpts.DisableRollback)
+	th.AssertDeepEquals(t, previewOpts.Parameters, createOpts.Parameters)
+	th.AssertDeepEquals(t, previewOpts.TemplateOpts.Bin, createOpts.TemplateOpts.Bin)
+	th.AssertDeepEquals(t, previewOpts.TemplateOpts.Files, createOpts.TemplateOpts.Files)
+
+	// Changing the create options must not alter the approved preview.
+	createOpts.Parameters["flavor"] = "m1.large"
+	createOpts.Parameters["networks"].([]interface{})[0] = "public"
+	createOpts.TemplateOpts.Files["my_nova.yaml"] = "changed"
+	*createOpts.DisableRollback = true
+	th.AssertEquals(t, "m1.tiny", previewOpts.Parameters["flavor"])
+	th.AssertEquals(t, "private", previewOpts.Parameters["networks"].([]interface{})[0])
+	th.AssertEquals(t, "heat_template_version: 2014-10-16", previewOpts.TemplateOpts.Files["my_nova.yaml"])
+	th.AssertEquals(t, false, *previewOpts.DisableRollback)
+}
+
+func TestPreviewStackConditions(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandlePreviewSuccessfully(t, PreviewConditionsOutput)
+
+	template := new(stacks.Template)
+	template.Bin = []byte(`heat_template_version: 2016-10-14`)
+	preview, err := stacks.Preview(fake.ServiceClient(), stacks.PreviewOpts{
+		Name:         "postman_stack",
+		Timeout:      5,
+		TemplateOpts: template,
+	}).Extract()
+	th.AssertNoErr(t, err)
+
+	resources, err := preview.ExtractResources()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, len(resources))
+
+	th.AssertEquals(t, "server", resources[0].Name)
+	th.AssertEquals(t, true, resources[0].Enabled())
+	th.AssertEquals(t, "volume", resources[1].Name)
+	th.AssertEquals(t, false, resources[1].Enabled())
+	th.AssertEquals(t, "port", resources[2].Name)
+	th.AssertEquals(t, "OS::Neutron::Port", resources[2].Type)
+	th.AssertEquals(t, true, resources[2].Condition == nil)
+	th.AssertEquals(t, true, resources[2].Enabled())
+}
+
+func TestPreviewStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandlePreviewSuccessfully(t, GetOutput)
+
+	template := new(stacks.Template)
+	template.Bin = []byte(`
+		{
+			"heat_template_version": "2013-05-23",
+			"description": "Simple template to test heat commands",
+			"parameters": {
+				"flavor": {
+					"default": "m1.tiny",
+					"type": "string"
+				}
+			}
+		}`)
+	previewOpts := stacks.PreviewOpts{
+		Name:            "stackcreated",
+		Timeout:         60,
+		TemplateOpts:    template,
+		DisableRollback: gophercloud.Disabled,
+	}
+	actual, err := stacks.Preview(fake.ServiceClient(), previewOpts).Extract()
+	th.AssertNoErr(t, err)
+
+	expected := PreviewExpected
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestAbandonStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleAbandonSuccessfully(t, AbandonOutput)
+
+	actual, err := stacks.Abandon(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c8").Extract()
+	th.AssertNoErr(t, err)
+
+	expected := AbandonExpected
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestAbandonAndAdoptStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleAbandonSuccessfully(t, AbandonOutput)
+
+	abandoned, err := stacks.Abandon(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c8").Extract()
+	th.AssertNoErr(t, err)
+
+	adoptOpts, err := abandoned.ToAdoptOpts("postman_stack_adopted")
+	th.AssertNoErr(t, err)
+	b, err := adoptOpts.ToStackAdoptMap()
+	th.AssertNoErr(t, err)
+
+	th.AssertEquals(t, "postman_stack_adopted", b["stack_name"])
+
+	var template, environment map[string]interface{}
+	th.AssertNoErr(t, json.Unmarshal([]byte(b["template"].(string)), &template))
+	th.AssertDeepEquals(t, AbandonExpected.Template, template)
+	th.AssertNoErr(t, json.Unmarshal([]byte(b["environment"].(string)), &environment))
+	th.AssertDeepEquals(t, fmt.Sprint(AbandonExpected.Environment), fmt.Sprint(environment))
+	th.AssertDeepEquals(t, AbandonExpected.Files, b["files"])
+
+	var data stacks.AbandonedStack
+	th.AssertNoErr(t, json.Unmarshal([]byte(b["adopt_stack_data"].(string)), &data))
+	th.AssertDeepEquals(t, AbandonExpected.Resources, data.Resources)
+}
+
+func TestAbandonedResourceRoundTrip(t *testing.T) {
+	var resource stacks.AbandonedResource
+	err := json.Unmarshal([]byte(AbandonedResourceOutput), &resource)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, AbandonedResourceExpected, resource)
+
+	// Re-map the physical resource and make sure nothing else is lost.
+	resource.ResourceID = "5b1c9a0e-3f2d-4e6b-8a7c-0d9e8f7a6b5c"
+	b, err := json.Marshal(resource)
+	th.AssertNoErr(t, err)
+
+	var expected, actual map[string]interface{}
+	th.AssertNoErr(t, json.Unmarshal([]byte(AbandonedResourceOutput), &expected))
+	th.AssertNoErr(t, json.Unmarshal(b, &actual))
+	expected["resource_id"] = "5b1c9a0e-3f2d-4e6b-8a7c-0d9e8f7a6b5c"
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestDeleteStackActionInProgress(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleConflict(t, ActionInProgressOutput)
+
+	err := stacks.Delete(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	inProgress, ok := err.(stacks.ErrActionInProgress)
+	th.AssertEquals(t, true, ok)
+	th.AssertEquals(t, "UPDATE", inProgress.Action)
+	th.AssertEquals(t, 409, inProgress.Actual)
+}
+
+func TestUpdatePatchStackConflict(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleConflict(t, ResourceConflictOutput)
+
+	updateOpts := &stacks.UpdateOpts{
+		Parameters: map[string]interface{}{"flavor": "m1.tiny"},
+	}
+	err := stacks.UpdatePatch(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateOpts).ExtractErr()
+	_, ok := err.(gophercloud.ErrDefault409)
+	th.AssertEquals(t, true, ok)
+	_, ok = err.(stacks.ErrActionInProgress)
+	th.AssertEquals(t, false, ok)
+}
+
+func TestIsStackLocked(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleConflict(t, ActionInProgressOutput)
+
+	err := stacks.Delete(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertEquals(t, true, stacks.IsStackLocked(err))
+
+	// Requests without the stack error context return an untyped 409.
+	_, err = fake.ServiceClient().Get(th.Endpoint()+"stacks/gophercloud-test-stack-2/db6977b2-27aa-4775-9ae7-6213212d4ada", nil, nil)
+	_, ok := err.(gophercloud.ErrUnexpectedResponseCode)
+	th.AssertEquals(t, true, ok)
+	th.AssertEquals(t, true, stacks.IsStackLocked(err))
+}
+
+func TestIsStackLockedOtherConflict(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleConflict(t, ResourceConflictOutput)
+
+	err := stacks.Delete(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertEquals(t, false, stacks.IsStackLocked(err))
+	th.AssertEquals(t, false, stacks.IsStackLocked(nil))
+	th.AssertEquals(t, false, stacks.IsStackLocked(fmt.Errorf("stack is locked")))
+}
+
+func TestGetAllStacks(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	found := []stacks.StackRef{
+		{Name: "web", ID: "4c3c5ed3-5a5e-4f3e-9b8a-1f3b2c4d5e6f"},
+		{Name: "db", ID: "8a7b6c5d-4e3f-4a1b-9c8d-7e6f5a4b3c2d"},
+	}
+	missing := stacks.StackRef{Name: "cache", ID: "0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f"}
+	peak := HandleGetAllSuccessfully(t, found...)
+
+	refs := append(found, missing)
+	results := stacks.GetAll(fake.ServiceClient(), refs, 2)
+	th.AssertEquals(t, len(refs), len(results))
+
+	for _, ref := range found {
+		stack, err := results[ref].Extract()
+		th.AssertNoErr(t, err)
+		th.AssertEquals(t, ref.ID, stack.ID)
+		th.AssertEquals(t, ref.Name, stack.Name)
+	}
+
+	_, err := results[missing].Extract()
+	if _, ok := err.(gophercloud.ErrDefault404); !ok {
+		t.Fatalf("expected a 404 for %v, got %v", missing, err)
+	}
+
+	if p := peak(); p > 2 {
+		t.Fatalf("expected at most 2 concurrent requests, got %d", p)
+	}
+}
+
+func TestDeleteBatch(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	deleted := []stacks.StackRef{
+		{Name: "web", ID: "4c3c5ed3-5a5e-4f3e-9b8a-1f3b2c4d5e6f"},
+		{Name: "db", ID: "8a7b6c5d-4e3f-4a1b-9c8d-7e6f5a4b3c2d"},
+	}
+	gone := stacks.StackRef{Name: "cache", ID: "0f1e2d3c-4b5a-4968-8776-5a4b3c2d1e0f"}
+	busy := stacks.StackRef{Name: "queue", ID: "5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9"}
+	HandleDeleteBatchSuccessfully(t, deleted...)
+	HandleDeleteConflict(t, busy)
+
+	refs := append(deleted, gone, busy)
+	results := stacks.DeleteBatch(fake.ServiceClient(), refs, 2)
+	th.AssertEquals(t, len(refs), len(results))
+
+	for _, ref := range append(deleted, gone) {
+		err, ok := results[ref.String()]
+		th.AssertEquals(t, true, ok)
+		th.AssertNoErr(t, err)
+	}
+
+	if _, ok := results["queue/5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9"].(gophercloud.ErrDefault409); !ok {
+		t.Fatalf("expected a 409 for %v, got %v", busy, results[busy.String()])
+	}
+}
+
+func TestDiffParameters(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetParametersOutput)
+
+	desired := map[string]string{
+		"flavor":  "m1.small",
+		"image":   "cirros-0.4.0",
+		"network": "private",
+	}
+	added, changed, removed, err := stacks.DiffParameters(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87", desired)
+	th.AssertNoErr(t, err)
+
+	th.AssertDeepEquals(t, map[string]string{"network": "private"}, added)
+	th.AssertDeepEquals(t, map[string]string{"flavor": "m1.small"}, changed)
+	th.AssertDeepEquals(t, map[string]string{"key_name": "heat_key"}, removed)
+}
+
+func TestTypedStackOutputs(t *testing.T) {
+	stack := stacks.RetrievedStack{
+		Outputs: []map[string]interface{}{
+			{"output_key": "server_ip", "output_value": "10.0.0.5"},
+			{"output_key": "port", "output_value": float64(8080)},
+			{"output_key": "id", "output_value": json.Number("9007199254740993")},
+			{"output_key": "count", "output_value": "3"},
+			{"output_key": "networks", "output_value": []interface{}{"private", "public"}},
+			{"output_key": "addresses", "output_value": map[string]interface{}{"private": "10.0.0.5"}},
+		},
+	}
+
+	ip, err := stack.OutputString("server_ip")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "10.0.0.5", ip)
+
+	port, err := stack.OutputInt("port")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 8080, port)
+
+	id, err := stack.OutputInt("id")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 9007199254740993, id)
+
+	count, err := stack.OutputInt("count")
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 3, count)
+
+	networks, err := stack.OutputStringSlice("networks")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{"private", "public"}, networks)
+
+	addresses, err := stack.OutputMap("addresses")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, map[string]interface{}{"private": "10.0.0.5"}, addresses)
+
+	for _, err := range []error{
+		func() error { _, err := stack.OutputString("port"); return err }(),
+		func() error { _, err := stack.OutputInt("server_ip"); return err }(),
+		func() error { _, err := stack.OutputStringSlice("addresses"); return err }(),
+		func() error { _, err := stack.OutputMap("networks"); return err }(),
+	} {
+		if _, ok := err.(stacks.ErrOutputType); !ok {
+			t.Errorf("expected an ErrOutputType, got %v", err)
+		}
+	}
+
+	_, err = stack.OutputString("missing")
+	if _, ok := err.(stacks.ErrOutputNotFound); !ok {
+		t.Errorf("expected an ErrOutputNotFound, got %v", err)
+	}
+}
+
+func TestGetStackOutput(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleGetSuccessfully(t, GetFailedOutputOutput)
+
+	stack, err := stacks.Get(fake.ServiceClient(), "postman_stack", "16ef0584-4458-41eb-87c8-0dc8d5f66c87").Extract()
+	th.AssertNoErr(t, err)
+
+	output, err := stack.Output("server_ip")
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, &stacks.Output{
+		Key:         "server_ip",
+		Value:       "10.0.0.5",
+		Description: "IP of the server",
+	}, output)
+
+	output, err = stack.Output("vip")
+	th.AssertEquals(t, "The Referenced Attribute (lb vip_address) is incorrect.", output.OutputError)
+	outputErr, ok := err.(stacks.ErrOutputError)
+	th.AssertEquals(t, true, ok)
+	th.AssertEquals(t, "vip", outputErr.Key)
+
+	_, err = stack.Output("missing")
+	_, ok = err.(stacks.ErrOutputNotFound)
+	th.AssertEquals(t, true, ok)
+}
+
+func TestRollbackToPrevious(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleRollbackToPrevious(t, GetWithPreviousTemplateOutput)
+
+	res, err := stacks.RollbackToPrevious(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada")
+	th.AssertNoErr(t, err)
+	th.AssertNoErr(t, res.ExtractErr())
+}
+
+func TestRollbackToPreviousBare(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleRollbackToPrevious(t, GetBareWithPreviousTemplateOutput)
+
+	res, err := stacks.RollbackToPrevious(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada")
+	th.AssertNoErr(t, err)
+	th.AssertNoErr(t, res.ExtractErr())
+}
+
+func TestRollbackToPreviousUnavailable(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleRollbackToPrevious(t, GetWithoutPreviousTemplateOutput)
+
+	_, err := stacks.RollbackToPrevious(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada")
+	_, ok := err.(stacks.ErrPreviousTemplateUnavailable)
+	th.AssertEquals(t, true, ok)
+}
+
+func TestValidateParameters(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleValidateSuccessfully(t, ValidateParametersOutput)
+
+	template := []byte(`heat_template_version: 2013-05-23`)
+
+	err := stacks.ValidateParameters(fake.ServiceClient(), template, map[string]string{
+		"key_name": "heat_key",
+		"count":    "3",
+	})
+	th.AssertNoErr(t, err)
+}
+
+func TestValidateParametersInvalid(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleValidateSuccessfully(t, ValidateParametersOutput)
+
+	template := []byte(`heat_template_version: 2013-05-23`)
+
+	err := stacks.ValidateParameters(fake.ServiceClient(), template, map[string]string{
+		"count": "three",
+	})
+	invalid, ok := err.(stacks.ErrInvalidParameters)
+	th.AssertEquals(t, true, ok)
+	th.AssertDeepEquals(t, []string{
+		`parameter [count] is not a valid Number: "three"`,
+		"missing required parameter [key_name]",
+	}, invalid.Problems)
+}
+
+func TestCancelUpdate(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleActionSuccessfully(t, `{"cancel_update": null}`)
+
+	err := stacks.CancelUpdate(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestCancelUpdateNoRollback(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleActionSuccessfully(t, `{"cancel_without_rollback": null}`)
+
+	err := stacks.CancelUpdateNoRollback(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada").ExtractErr()
+	th.AssertNoErr(t, err)
 }
@@ -0,0 +1,549 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stacks"
+	th "github.com/gophercloud/gophercloud/testhelper"
+	fake "github.com/gophercloud/gophercloud/testhelper/client"
+)
+
+const createdStackID = "16ef0584-4458-41eb-87c8-0dc8d5f66c87"
+
+func setPollInterval(t *testing.T, d time.Duration) {
+	old := stacks.PollInterval
+	stacks.PollInterval = d
+	t.Cleanup(func() { stacks.PollInterval = old })
+}
+
+func setSnapshotWaitTimeout(t *testing.T, d time.Duration) {
+	old := stacks.SnapshotWaitTimeout
+	stacks.SnapshotWaitTimeout = d
+	t.Cleanup(func() { stacks.SnapshotWaitTimeout = old })
+}
+
+func createAndWaitOpts() stacks.CreateOpts {
+	template := new(stacks.Template)
+	template.Bin = []byte(`{"heat_template_version": "2013-05-23"}`)
+	return stacks.CreateOpts{
+		Name:         "stackcreated",
+		TemplateOpts: template,
+	}
+}
+
+func TestWaitForStatus(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusUpdateInProgress, stacks.StatusUpdateInProgress, stacks.StatusUpdateComplete)
+
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete, time.Second)
+	th.AssertNoErr(t, err)
+}
+
+func TestWaitForStatusProgress(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusCreateInProgress, stacks.StatusCreateInProgress, stacks.StatusCreateComplete)
+	HandleResourcesSequence(t, "stackcreated", createdStackID, 3, 0, 2, 3)
+
+	var progress []stacks.Progress
+	opts := stacks.WaitOpts{
+		OnProgress:     func(p stacks.Progress) { progress = append(progress, p) },
+		CountResources: true,
+	}
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusCreateComplete, time.Second, opts)
+	th.AssertNoErr(t, err)
+
+	expected := []stacks.Progress{
+		{Status: stacks.StatusCreateInProgress, ResourcesComplete: 0, ResourcesTotal: 3},
+		{Status: stacks.StatusCreateInProgress, ResourcesComplete: 2, ResourcesTotal: 3},
+		{Status: stacks.StatusCreateComplete, ResourcesComplete: 3, ResourcesTotal: 3},
+	}
+	th.AssertDeepEquals(t, expected, progress)
+}
+
+func TestWaitForStatusProgressWithoutResources(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusUpdateInProgress, stacks.StatusUpdateComplete)
+
+	var statuses []string
+	opts := stacks.WaitOpts{
+		OnProgress: func(p stacks.Progress) { statuses = append(statuses, p.Status) },
+	}
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete, time.Second, opts)
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{stacks.StatusUpdateInProgress, stacks.StatusUpdateComplete}, statuses)
+}
+
+func TestWaitForStatusRetryAfter(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	calls := HandleGetRateLimited(t, "stackcreated", createdStackID, "1", stacks.StatusUpdateComplete)
+
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete, 5*time.Second)
+	th.AssertNoErr(t, err)
+
+	times := calls()
+	th.AssertEquals(t, 2, len(times))
+	if d := times[1].Sub(times[0]); d < time.Second {
+		t.Fatalf("expected to wait for the Retry-After delay, polled again after %v", d)
+	}
+}
+
+func TestWaitForStatusRetryAfterTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetRateLimited(t, "stackcreated", createdStackID, "60", stacks.StatusUpdateComplete)
+
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete, time.Second)
+	if _, ok := err.(gophercloud.ErrDefault429); !ok {
+		t.Fatalf("expected the 429 to be returned, got %v", err)
+	}
+}
+
+func TestWaitForStatusTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, 5*time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID, stacks.StatusUpdateInProgress)
+
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete, 20*time.Millisecond)
+	timeout, ok := err.(stacks.ErrWaitTimeout)
+	if !ok {
+		t.Fatalf("expected ErrWaitTimeout, got %#v", err)
+	}
+	th.AssertEquals(t, stacks.StatusUpdateInProgress, timeout.LastStatus)
+}
+
+func TestCreateAndWait(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleCreateSuccessfully(t, CreateOutput)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusCreateInProgress, stacks.StatusCreateComplete)
+
+	stack, err := stacks.CreateAndWait(fake.ServiceClient(), createAndWaitOpts(), time.Second)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, createdStackID, stack.ID)
+	th.AssertEquals(t, stacks.StatusCreateComplete, stack.Status)
+}
+
+// fileRefTemplate writes a child template to a temporary directory and
+// returns a template referencing it with get_file by a relative path, along
+// with the template and files a request built from it must carry.
+func fileRefTemplate(t *testing.T) (template *stacks.Template, sentTemplate string, files map[string]string) {
+	dir, err := ioutil.TempDir(".", "stacks")
+	th.AssertNoErr(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	child := filepath.Join(dir, "child.yaml")
+	th.AssertNoErr(t, ioutil.WriteFile(child, []byte("heat_template_version: 2013-05-23\n"), 0644))
+	abs, err := filepath.Abs(child)
+	th.AssertNoErr(t, err)
+
+	bin := "heat_template_version: 2013-05-23\nresources:\n  config:\n    type: OS::Heat::SoftwareConfig\n    properties:\n      config: {get_file: %s}\n"
+	template = new(stacks.Template)
+	template.Bin = []byte(fmt.Sprintf(bin, child))
+	sentTemplate = fmt.Sprintf(bin, "file://"+abs)
+	files = map[string]string{"file://" + abs: "heat_template_version: 2013-05-23\n"}
+	return
+}
+
+func TestCreateAndWaitFileRefs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	template, sentTemplate, files := fileRefTemplate(t)
+	body, err := json.Marshal(map[string]interface{}{
+		"stack_name": "stackcreated",
+		"template":   sentTemplate,
+		"files":      files,
+	})
+	th.AssertNoErr(t, err)
+	HandleCreateRawBody(t, string(body))
+	HandleGetStatusSequence(t, "stackcreated", createdStackID, stacks.StatusCreateComplete)
+
+	opts := stacks.CreateOpts{Name: "stackcreated", TemplateOpts: template}
+	_, err = stacks.CreateAndWait(fake.ServiceClient(), opts, time.Second)
+	th.AssertNoErr(t, err)
+}
+
+func TestCreateAndWaitFailed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleCreateSuccessfully(t, CreateOutput)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusCreateInProgress, stacks.StatusCreateFailed)
+	HandleStackEventsSuccessfully(t, "stackcreated", createdStackID, StackEventsOutput)
+
+	stack, err := stacks.CreateAndWait(fake.ServiceClient(), createAndWaitOpts(), time.Second)
+	failed, ok := err.(stacks.ErrStackFailed)
+	if !ok {
+		t.Fatalf("expected ErrStackFailed, got %#v", err)
+	}
+	th.AssertEquals(t, stacks.StatusCreateFailed, stack.Status)
+	th.AssertEquals(t, stacks.StatusCreateFailed, failed.Status)
+	th.AssertEquals(t, "Stack create_failed", failed.Reason)
+	th.AssertEquals(t, 2, len(failed.Events))
+	th.AssertEquals(t, "Quota exceeded", failed.Events[0].ResourceStatusReason)
+}
+
+func TestCreateAndWaitRolledBack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleCreateSuccessfully(t, CreateOutput)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusCreateInProgress, stacks.StatusRollbackInProgress, stacks.StatusRollbackComplete)
+	HandleStackEventsSuccessfully(t, "stackcreated", createdStackID, StackEventsOutput)
+
+	stack, err := stacks.CreateAndWait(fake.ServiceClient(), createAndWaitOpts(), 0)
+	failed, ok := err.(stacks.ErrStackFailed)
+	if !ok {
+		t.Fatalf("expected ErrStackFailed, got %#v", err)
+	}
+	th.AssertEquals(t, stacks.StatusRollbackComplete, stack.Status)
+	th.AssertEquals(t, stacks.StatusRollbackComplete, failed.Status)
+	th.AssertEquals(t, 2, len(failed.Events))
+}
+
+func TestWaitForStatusCreateSettledElsewhere(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusCreateInProgress, stacks.StatusDeleteComplete)
+
+	err := stacks.WaitForStatus(fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusCreateComplete, time.Second)
+	failed, ok := err.(stacks.ErrStackFailed)
+	if !ok {
+		t.Fatalf("expected ErrStackFailed, got %#v", err)
+	}
+	th.AssertEquals(t, stacks.StatusDeleteComplete, failed.Status)
+}
+
+func adoptAndWaitOpts() stacks.AdoptOpts {
+	template := new(stacks.Template)
+	template.Bin = []byte(`{"heat_template_version": "2013-05-23"}`)
+	return stacks.AdoptOpts{
+		AdoptStackData: "{}",
+		Name:           "stackcreated",
+		Timeout:        60,
+		TemplateOpts:   template,
+	}
+}
+
+func TestAdoptAndWait(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleCreateSuccessfully(t, CreateOutput)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusAdoptInProgress, stacks.StatusAdoptComplete)
+
+	stack, err := stacks.AdoptAndWait(fake.ServiceClient(), adoptAndWaitOpts(), time.Second)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, createdStackID, stack.ID)
+	th.AssertEquals(t, stacks.StatusAdoptComplete, stack.Status)
+}
+
+func TestAdoptAndWaitFileRefs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	template, sentTemplate, files := fileRefTemplate(t)
+	body, err := json.Marshal(map[string]interface{}{
+		"adopt_stack_data": "{}",
+		"stack_name":       "stackcreated",
+		"timeout_mins":     60,
+		"template":         sentTemplate,
+		"files":            files,
+	})
+	th.AssertNoErr(t, err)
+	HandleCreateRawBody(t, string(body))
+	HandleGetStatusSequence(t, "stackcreated", createdStackID, stacks.StatusAdoptComplete)
+
+	opts := adoptAndWaitOpts()
+	opts.TemplateOpts = template
+	_, err = stacks.AdoptAndWait(fake.ServiceClient(), opts, time.Second)
+	th.AssertNoErr(t, err)
+}
+
+func TestAdoptAndWaitFailed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleCreateSuccessfully(t, CreateOutput)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusAdoptInProgress, stacks.StatusAdoptFailed)
+	HandleStackEventsSuccessfully(t, "stackcreated", createdStackID, StackEventsOutput)
+
+	stack, err := stacks.AdoptAndWait(fake.ServiceClient(), adoptAndWaitOpts(), time.Second)
+	failed, ok := err.(stacks.ErrStackFailed)
+	if !ok {
+		t.Fatalf("expected ErrStackFailed, got %#v", err)
+	}
+	th.AssertEquals(t, stacks.StatusAdoptFailed, stack.Status)
+	th.AssertEquals(t, stacks.StatusAdoptFailed, failed.Status)
+	th.AssertEquals(t, 2, len(failed.Events))
+}
+
+func TestWaitForStatusContext(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID,
+		stacks.StatusUpdateInProgress, stacks.StatusUpdateComplete)
+
+	err := stacks.WaitForStatusContext(context.Background(), fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete)
+	th.AssertNoErr(t, err)
+}
+
+func TestWaitForStatusContextCancelled(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	// A long poll interval makes sure cancellation interrupts the sleep
+	// between two polls rather than being noticed on the next poll.
+	setPollInterval(t, time.Hour)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID, stacks.StatusUpdateInProgress)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- stacks.WaitForStatusContext(ctx, fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete)
+	}()
+
+	select {
+	case err := <-done:
+		th.AssertEquals(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitForStatusContext did not return after the context was cancelled")
+	}
+}
+
+func TestWaitForStatusContextDeadline(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetStatusSequence(t, "stackcreated", createdStackID, stacks.StatusUpdateInProgress)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := stacks.WaitForStatusContext(ctx, fake.ServiceClient(), "stackcreated", createdStackID, stacks.StatusUpdateComplete)
+	th.AssertEquals(t, context.DeadlineExceeded, err)
+}
+
+func updateWithSnapshotOpts() *stacks.UpdateOpts {
+	template := new(stacks.Template)
+	template.Bin = []byte(`{"heat_template_version": "2013-05-23"}`)
+	return &stacks.UpdateOpts{TemplateOpts: template}
+}
+
+func TestUpdateWithSnapshot(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleSnapshotSequence(t, stacks.SnapshotStatusInProgress, stacks.SnapshotStatusInProgress, stacks.SnapshotStatusComplete)
+	HandleUpdateSuccessfully(t)
+
+	snapshotID, res := stacks.UpdateWithSnapshot(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateWithSnapshotOpts())
+	th.AssertNoErr(t, res.ExtractErr())
+	th.AssertEquals(t, "a8a0b8bd-6e71-4f8e-8c3a-9a1b2c3d4e5f", snapshotID)
+}
+
+func TestUpdateWithSnapshotFailed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleSnapshotSequence(t, stacks.SnapshotStatusInProgress, stacks.SnapshotStatusFailed)
+	HandleUpdateForbidden(t)
+
+	snapshotID, res := stacks.UpdateWithSnapshot(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateWithSnapshotOpts())
+	th.AssertEquals(t, "a8a0b8bd-6e71-4f8e-8c3a-9a1b2c3d4e5f", snapshotID)
+	failed, ok := res.ExtractErr().(stacks.ErrSnapshotFailed)
+	if !ok {
+		t.Fatalf("expected ErrSnapshotFailed, got %#v", res.ExtractErr())
+	}
+	th.AssertEquals(t, "snapshot failed", failed.Reason)
+}
+
+func TestUpdateWithSnapshotTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, 5*time.Millisecond)
+	HandleSnapshotSequence(t, stacks.SnapshotStatusInProgress)
+	HandleUpdateForbidden(t)
+
+	setSnapshotWaitTimeout(t, 50*time.Millisecond)
+	snapshotID, res := stacks.UpdateWithSnapshot(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", updateWithSnapshotOpts())
+	th.AssertEquals(t, "a8a0b8bd-6e71-4f8e-8c3a-9a1b2c3d4e5f", snapshotID)
+	timeout, ok := res.ExtractErr().(stacks.ErrWaitTimeout)
+	if !ok {
+		t.Fatalf("expected ErrWaitTimeout, got %#v", res.ExtractErr())
+	}
+	th.AssertEquals(t, stacks.SnapshotStatusInProgress, timeout.LastStatus)
+}
+
+func TestFailureReasons(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleStackEventsSuccessfully(t, "stackcreated", createdStackID, FailedStackEventsOutput)
+
+	actual, err := stacks.FailureReasons(fake.ServiceClient(), "stackcreated", createdStackID)
+	th.AssertNoErr(t, err)
+
+	expected := []stacks.ResourceFailure{
+		{
+			ResourceName: "port",
+			StatusReason: "Conflict: IP address already allocated",
+			Time:         time.Date(2018, 6, 26, 7, 58, 30, 0, time.UTC),
+		},
+		{
+			ResourceName: "server",
+			StatusReason: "CREATE aborted",
+			Time:         time.Date(2018, 6, 26, 7, 59, 0, 0, time.UTC),
+		},
+	}
+	th.AssertDeepEquals(t, expected, actual)
+}
+
+func TestResourceProgress(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleNestedResources(t, "stackcreated", createdStackID)
+
+	done, total, err := stacks.ResourceProgress(fake.ServiceClient(), "stackcreated", createdStackID, 0)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 1, done)
+	th.AssertEquals(t, 2, total)
+
+	done, total, err = stacks.ResourceProgress(fake.ServiceClient(), "stackcreated", createdStackID, 1)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, 2, done)
+	th.AssertEquals(t, 4, total)
+}
+
+func TestCancelUpdateAndWait(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleActionSuccessfully(t, `{"cancel_update": null}`)
+	HandleGetStatusSequence(t, "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada",
+		stacks.StatusUpdateInProgress, stacks.StatusUpdateFailed, stacks.StatusRollbackInProgress, stacks.StatusRollbackComplete)
+
+	err := stacks.CancelUpdateAndWait(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", time.Second)
+	th.AssertNoErr(t, err)
+}
+
+func TestCancelUpdateAndWaitRollbackFailed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleActionSuccessfully(t, `{"cancel_update": null}`)
+	HandleGetStatusSequence(t, "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada",
+		stacks.StatusRollbackInProgress, stacks.StatusRollbackFailed)
+
+	err := stacks.CancelUpdateAndWait(fake.ServiceClient(), "gophercloud-test-stack-2", "db6977b2-27aa-4775-9ae7-6213212d4ada", time.Second)
+	failed, ok := err.(stacks.ErrStackFailed)
+	if !ok {
+		t.Fatalf("expected an ErrStackFailed, got %v", err)
+	}
+	th.AssertEquals(t, stacks.StatusRollbackFailed, failed.Status)
+}
+
+func stackWithOutput(status, output string) string {
+	return `{"id": "16ef0584-4458-41eb-87c8-0dc8d5f66c87", "stack_name": "stackcreated", "stack_status": "` + status + `",
+		"stack_status_reason": "Stack ` + status + `", "outputs": [` + output + `]}`
+}
+
+func TestWaitForOutput(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetBodySequence(t, "stackcreated", createdStackID,
+		stackWithOutput(stacks.StatusCreateInProgress, ""),
+		stackWithOutput(stacks.StatusCreateInProgress, `{"output_key": "vip", "output_value": null, "output_error": "vip_address not ready"}`),
+		stackWithOutput(stacks.StatusCreateInProgress, `{"output_key": "vip", "output_value": ""}`),
+		stackWithOutput(stacks.StatusCreateComplete, `{"output_key": "vip", "output_value": "10.0.0.5"}`))
+
+	vip, err := stacks.WaitForOutput(fake.ServiceClient(), "stackcreated", createdStackID, "vip", time.Second)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "10.0.0.5", vip)
+}
+
+func TestWaitForOutputStackFailed(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetBodySequence(t, "stackcreated", createdStackID,
+		stackWithOutput(stacks.StatusCreateInProgress, ""),
+		stackWithOutput(stacks.StatusCreateFailed, ""))
+
+	_, err := stacks.WaitForOutput(fake.ServiceClient(), "stackcreated", createdStackID, "vip", time.Second)
+	failed, ok := err.(stacks.ErrStackFailed)
+	if !ok {
+		t.Fatalf("expected an ErrStackFailed, got %v", err)
+	}
+	th.AssertEquals(t, "Stack CREATE_FAILED", failed.Reason)
+}
+
+func TestWaitForOutputMissing(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetBodySequence(t, "stackcreated", createdStackID, stackWithOutput(stacks.StatusCreateComplete, ""))
+
+	_, err := stacks.WaitForOutput(fake.ServiceClient(), "stackcreated", createdStackID, "vip", time.Second)
+	if _, ok := err.(stacks.ErrOutputNotFound); !ok {
+		t.Fatalf("expected an ErrOutputNotFound, got %v", err)
+	}
+}
+
+func TestWaitForOutputContextDeadline(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, time.Millisecond)
+	HandleGetBodySequence(t, "stackcreated", createdStackID, stackWithOutput(stacks.StatusCreateInProgress, ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := stacks.WaitForOutputContext(ctx, fake.ServiceClient(), "stackcreated", createdStackID, "vip")
+	th.AssertEquals(t, context.DeadlineExceeded, err)
+}
+
+func TestWaitForOutputTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	setPollInterval(t, 5*time.Millisecond)
+	HandleGetBodySequence(t, "stackcreated", createdStackID, stackWithOutput(stacks.StatusCreateInProgress, ""))
+
+	_, err := stacks.WaitForOutput(fake.ServiceClient(), "stackcreated", createdStackID, "vip", 50*time.Millisecond)
+	timeout, ok := err.(stacks.ErrWaitTimeout)
+	if !ok {
+		t.Fatalf("expected an ErrWaitTimeout, got %v", err)
+	}
+	th.AssertEquals(t, "a value for output vip", timeout.Status)
+}
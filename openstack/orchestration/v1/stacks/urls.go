@@ -1,6 +1,10 @@
 package stacks
 
-import "github.com/gophercloud/gophercloud"
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+)
 
 func createURL(c *gophercloud.ServiceClient) string {
 	return c.ServiceURL("stacks")
@@ -14,14 +18,36 @@ func listURL(c *gophercloud.ServiceClient) string {
 	return createURL(c)
 }
 
+func listDetailURL(c *gophercloud.ServiceClient) string {
+	return c.ServiceURL("stacks", "detail")
+}
+
 func getURL(c *gophercloud.ServiceClient, name, id string) string {
 	return c.ServiceURL("stacks", name, id)
 }
 
+// tenantURL builds a URL like ServiceURL, but with the tenant segment that
+// ends Heat endpoints (".../v1/<tenant_id>/") replaced by tenantID.
+func tenantURL(c *gophercloud.ServiceClient, tenantID string, parts ...string) string {
+	base := strings.TrimSuffix(c.ResourceBaseURL(), "/")
+	if i := strings.LastIndex(base, "/"); i > strings.Index(base, "//")+1 {
+		base = base[:i]
+	}
+	return base + "/" + tenantID + "/" + strings.Join(parts, "/")
+}
+
+func getForTenantURL(c *gophercloud.ServiceClient, tenantID, name, id string) string {
+	return tenantURL(c, tenantID, "stacks", name, id)
+}
+
 func updateURL(c *gophercloud.ServiceClient, name, id string) string {
 	return getURL(c, name, id)
 }
 
+func updatePreviewURL(c *gophercloud.ServiceClient, name, id string) string {
+	return c.ServiceURL("stacks", name, id, "preview")
+}
+
 func deleteURL(c *gophercloud.ServiceClient, name, id string) string {
 	return getURL(c, name, id)
 }
@@ -33,3 +59,15 @@ func previewURL(c *gophercloud.ServiceClient) string {
 func abandonURL(c *gophercloud.ServiceClient, name, id string) string {
 	return c.ServiceURL("stacks", name, id, "abandon")
 }
+
+func actionURL(c *gophercloud.ServiceClient, name, id string) string {
+	return c.ServiceURL("stacks", name, id, "actions")
+}
+
+func snapshotsURL(c *gophercloud.ServiceClient, name, id string) string {
+	return c.ServiceURL("stacks", name, id, "snapshots")
+}
+
+func snapshotURL(c *gophercloud.ServiceClient, name, id, snapshotID string) string {
+	return c.ServiceURL("stacks", name, id, "snapshots", snapshotID)
+}
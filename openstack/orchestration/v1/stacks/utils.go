@@ -85,6 +85,180 @@ func (t *TE) Fetch() error {
 	return nil
 }
 
+// clone returns a copy of the TE that shares no maps or slices with the
+// original, so that either can be modified without affecting the other.
+func (t TE) clone() TE {
+	c := t
+	if t.Bin != nil {
+		c.Bin = append([]byte(nil), t.Bin...)
+	}
+	if t.Parsed != nil {
+		c.Parsed = deepCopy(t.Parsed).(map[string]interface{})
+	}
+	c.Files = copyStringMap(t.Files)
+	c.fileMaps = copyStringMap(t.fileMaps)
+	return c
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// deepCopy copies the maps and slices of a decoded JSON or YAML value.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = deepCopy(e)
+		}
+		return c
+	case map[interface{}]interface{}:
+		c := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			c[k] = deepCopy(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = deepCopy(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// CheckBodySize makes the To*Map methods of this package return an
+// ErrBodyTooLarge when the serialized request body is larger than
+// MaxBodySize, instead of letting Heat reject it with a 413. It is off by
+// default.
+var CheckBodySize = false
+
+// MaxBodySize is the largest request body, in bytes, accepted when
+// CheckBodySize is enabled. It defaults to the default max_json_body_size of
+// Heat.
+var MaxBodySize = 1048576
+
+// buildStackMap builds the request body of a stack operation from an options
+// struct. Fields are first converted by gophercloud.BuildRequestBody, which
+// honors their json (including omitempty) and required tags. Fields tagged
+// with `stack:"..."` are then added: "template" and "environment" are parsed
+// and inlined along with the files they reference, "files" are added to
+// those, and "tags" are joined with commas.
+func buildStackMap(opts interface{}) (map[string]interface{}, error) {
+	b, err := gophercloud.BuildRequestBody(opts, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var template *Template
+	var environment *Environment
+	var tags []string
+	var extraFiles map[string]interface{}
+	var extraFields map[string]interface{}
+
+	v := reflect.Indirect(reflect.ValueOf(opts))
+	for i := 0; i < v.NumField(); i++ {
+		switch v.Type().Field(i).Tag.Get("stack") {
+		case "template":
+			template, _ = v.Field(i).Interface().(*Template)
+		case "environment":
+			environment, _ = v.Field(i).Interface().(*Environment)
+		case "tags":
+			tags, _ = v.Field(i).Interface().([]string)
+		case "files":
+			extraFiles, _ = v.Field(i).Interface().(map[string]interface{})
+		case "extra":
+			extraFields, _ = v.Field(i).Interface().(map[string]interface{})
+		}
+	}
+
+	files := make(map[string]string)
+
+	if template != nil {
+		if err := template.Parse(); err != nil {
+			return nil, err
+		}
+		if err := template.getFileContents(template.Parsed, ignoreIfTemplate, true); err != nil {
+			return nil, err
+		}
+		template.fixFileRefs()
+		b["template"] = string(template.Bin)
+
+		for k, v := range template.Files {
+			files[k] = v
+		}
+	}
+
+	if environment != nil {
+		if err := environment.Parse(); err != nil {
+			return nil, err
+		}
+		if err := environment.getRRFileContents(ignoreIfEnvironment); err != nil {
+			return nil, err
+		}
+		environment.fixFileRefs()
+		for k, v := range environment.Files {
+			files[k] = v
+		}
+		// An environment without any section is left out, as some Heat
+		// versions reject an empty one.
+		if len(environment.Parsed) > 0 {
+			b["environment"] = string(environment.Bin)
+		}
+	}
+
+	for k, v := range extraFiles {
+		switch content := v.(type) {
+		case string:
+			files[k] = content
+		case []byte:
+			files[k] = string(content)
+		default:
+			err := gophercloud.ErrInvalidInput{}
+			err.Argument = "stacks.CreateOpts.Files"
+			err.Value = v
+			err.Info = "Files values must be strings or byte slices"
+			return nil, err
+		}
+	}
+
+	if len(files) > 0 {
+		b["files"] = files
+	}
+
+	if tags != nil {
+		b["tags"] = strings.Join(tags, ",")
+	}
+
+	for k, v := range extraFields {
+		if _, ok := b[k]; !ok {
+			b[k] = v
+		}
+	}
+
+	if CheckBodySize {
+		body, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > MaxBodySize {
+			return nil, ErrBodyTooLarge{Size: len(body), Limit: MaxBodySize}
+		}
+	}
+
+	return b, nil
+}
+
 // get the basepath of the TE
 func getBasePath() (string, error) {
 	basePath, err := filepath.Abs(".")
@@ -101,11 +275,57 @@ func getBasePath() (string, error) {
 // get a an HTTP client to retrieve URL's. This client allows the use of `file`
 // scheme since we may need to fetch files from users filesystem
 func getHTTPClient() Client {
+	return newHTTPClient()
+}
+
+func newHTTPClient() *http.Client {
 	transport := &http.Transport{}
 	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
 	return &http.Client{Transport: transport}
 }
 
+// headerClient is a Client that adds a fixed set of headers to each request,
+// e.g. to fetch templates from a server requiring authentication.
+type headerClient struct {
+	client  *http.Client
+	headers map[string]string
+}
+
+func (c headerClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Failed to fetch %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// filesClient is a Client serving the files of a stack, e.g. those returned
+// by Abandon, instead of fetching them again.
+type filesClient map[string]string
+
+func (c filesClient) Get(url string) (*http.Response, error) {
+	content, ok := c[url]
+	if !ok {
+		return nil, fmt.Errorf("File %s is not among the stack files", url)
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(content)),
+	}, nil
+}
+
 // Parse will parse the contents and then validate. The contents MUST be either JSON or YAML.
 func (t *TE) Parse() error {
 	if err := t.Fetch(); err != nil {
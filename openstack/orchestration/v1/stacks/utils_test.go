@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/gophercloud/gophercloud"
 	th "github.com/gophercloud/gophercloud/testhelper"
 )
 
@@ -92,3 +93,142 @@ func TestFetch(t *testing.T) {
 	th.AssertEquals(t, fakeURL, te.URL)
 	th.AssertEquals(t, "Fee-fi-fo-fum", string(te.Bin))
 }
+
+// handCodedStackMap reproduces how the To*Map functions built stack request
+// bodies before buildStackMap, to make sure the output did not change.
+func handCodedStackMap(opts interface{}, template *Template, environment *Environment, tags []string) (map[string]interface{}, error) {
+	b, err := gophercloud.BuildRequestBody(opts, "")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	if template != nil {
+		if err := template.Parse(); err != nil {
+			return nil, err
+		}
+		if err := template.getFileContents(template.Parsed, ignoreIfTemplate, true); err != nil {
+			return nil, err
+		}
+		template.fixFileRefs()
+		b["template"] = string(template.Bin)
+		for k, v := range template.Files {
+			files[k] = v
+		}
+	}
+	if environment != nil {
+		if err := environment.Parse(); err != nil {
+			return nil, err
+		}
+		if err := environment.getRRFileContents(ignoreIfEnvironment); err != nil {
+			return nil, err
+		}
+		environment.fixFileRefs()
+		for k, v := range environment.Files {
+			files[k] = v
+		}
+		b["environment"] = string(environment.Bin)
+	}
+	if len(files) > 0 {
+		b["files"] = files
+	}
+	if tags != nil {
+		b["tags"] = strings.Join(tags, ",")
+	}
+	return b, nil
+}
+
+func TestBuildStackMapMatchesHandCoded(t *testing.T) {
+	newTemplate := func() *Template {
+		template := new(Template)
+		template.Bin = []byte(`{"heat_template_version": "2013-05-23", "parameters": {"flavor": {"type": "string"}}}`)
+		template.Files = map[string]string{"my_nova.yaml": "heat_template_version: 2014-10-16"}
+		return template
+	}
+	newEnvironment := func() *Environment {
+		environment := new(Environment)
+		environment.Bin = []byte(`{"parameters": {"flavor": "m1.tiny"}}`)
+		return environment
+	}
+	parameters := map[string]interface{}{"flavor": "m1.tiny"}
+
+	cases := []struct {
+		name     string
+		build    func() (map[string]interface{}, error)
+		expected func() (map[string]interface{}, error)
+	}{
+		{
+			name: "create",
+			build: func() (map[string]interface{}, error) {
+				return CreateOpts{Name: "stack", TemplateOpts: newTemplate(), EnvironmentOpts: newEnvironment(),
+					Parameters: parameters, Timeout: 60, DisableRollback: gophercloud.Disabled, Tags: []string{"a", "b"}}.ToStackCreateMap()
+			},
+			expected: func() (map[string]interface{}, error) {
+				opts := CreateOpts{Name: "stack", TemplateOpts: newTemplate(), EnvironmentOpts: newEnvironment(),
+					Parameters: parameters, Timeout: 60, DisableRollback: gophercloud.Disabled, Tags: []string{"a", "b"}}
+				return handCodedStackMap(opts, opts.TemplateOpts, opts.EnvironmentOpts, opts.Tags)
+			},
+		},
+		{
+			name: "create minimal",
+			build: func() (map[string]interface{}, error) {
+				return CreateOpts{Name: "stack", TemplateOpts: newTemplate()}.ToStackCreateMap()
+			},
+			expected: func() (map[string]interface{}, error) {
+				opts := CreateOpts{Name: "stack", TemplateOpts: newTemplate()}
+				return handCodedStackMap(opts, opts.TemplateOpts, nil, nil)
+			},
+		},
+		{
+			name: "adopt",
+			build: func() (map[string]interface{}, error) {
+				return AdoptOpts{Name: "stack", AdoptStackData: "{}", TemplateOpts: newTemplate(), EnvironmentOpts: newEnvironment()}.ToStackAdoptMap()
+			},
+			expected: func() (map[string]interface{}, error) {
+				opts := AdoptOpts{Name: "stack", AdoptStackData: "{}", TemplateOpts: newTemplate(), EnvironmentOpts: newEnvironment()}
+				return handCodedStackMap(opts, opts.TemplateOpts, opts.EnvironmentOpts, nil)
+			},
+		},
+		{
+			name: "preview",
+			build: func() (map[string]interface{}, error) {
+				return PreviewOpts{Name: "stack", Timeout: 30, TemplateOpts: newTemplate(), Parameters: parameters}.ToStackPreviewMap()
+			},
+			expected: func() (map[string]interface{}, error) {
+				opts := PreviewOpts{Name: "stack", Timeout: 30, TemplateOpts: newTemplate(), Parameters: parameters}
+				return handCodedStackMap(opts, opts.TemplateOpts, nil, nil)
+			},
+		},
+		{
+			name: "update patch without template",
+			build: func() (map[string]interface{}, error) {
+				return UpdateOpts{Parameters: parameters, Tags: []string{}}.ToStackUpdatePatchMap()
+			},
+			expected: func() (map[string]interface{}, error) {
+				opts := UpdateOpts{Parameters: parameters, Tags: []string{}}
+				return handCodedStackMap(opts, nil, nil, opts.Tags)
+			},
+		},
+		{
+			name: "missing required name",
+			build: func() (map[string]interface{}, error) {
+				return CreateOpts{TemplateOpts: newTemplate()}.ToStackCreateMap()
+			},
+			expected: func() (map[string]interface{}, error) {
+				opts := CreateOpts{TemplateOpts: newTemplate()}
+				return handCodedStackMap(opts, opts.TemplateOpts, nil, nil)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, expectedErr := tc.expected()
+			actual, err := tc.build()
+			if expectedErr != nil || err != nil {
+				th.AssertEquals(t, fmt.Sprint(expectedErr), fmt.Sprint(err))
+			}
+			th.AssertDeepEquals(t, expected, actual)
+		})
+	}
+}
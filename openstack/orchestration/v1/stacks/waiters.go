@@ -0,0 +1,403 @@
+package stacks
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/internal/polling"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackevents"
+	"github.com/gophercloud/gophercloud/openstack/orchestration/v1/stackresources"
+)
+
+// Stack statuses reported by Heat.
+const (
+	StatusCreateInProgress   = "CREATE_IN_PROGRESS"
+	StatusCreateComplete     = "CREATE_COMPLETE"
+	StatusCreateFailed       = "CREATE_FAILED"
+	StatusUpdateInProgress   = "UPDATE_IN_PROGRESS"
+	StatusUpdateComplete     = "UPDATE_COMPLETE"
+	StatusUpdateFailed       = "UPDATE_FAILED"
+	StatusDeleteInProgress   = "DELETE_IN_PROGRESS"
+	StatusDeleteComplete     = "DELETE_COMPLETE"
+	StatusDeleteFailed       = "DELETE_FAILED"
+	StatusRollbackInProgress = "ROLLBACK_IN_PROGRESS"
+	StatusRollbackComplete   = "ROLLBACK_COMPLETE"
+	StatusRollbackFailed     = "ROLLBACK_FAILED"
+	StatusAdoptInProgress    = "ADOPT_IN_PROGRESS"
+	StatusAdoptComplete      = "ADOPT_COMPLETE"
+	StatusAdoptFailed        = "ADOPT_FAILED"
+)
+
+// PollInterval is the time the waiters in this package sleep between two
+// consecutive polls of a stack.
+var PollInterval = time.Second
+
+// SnapshotWaitTimeout is how long UpdateWithSnapshot waits for its snapshot to
+// complete. A value of zero or less waits indefinitely.
+var SnapshotWaitTimeout = 30 * time.Minute
+
+// failureEventCount is the number of most recent events attached to an
+// ErrStackFailed by CreateAndWait.
+const failureEventCount = 10
+
+// Progress describes the state of a stack on one poll of a waiter.
+type Progress struct {
+	// Status is the current status of the stack.
+	Status string
+	// ResourcesComplete and ResourcesTotal are the number of resources of the
+	// stack in a *_COMPLETE status and the total number of resources. They
+	// are only set if WaitOpts.CountResources is true.
+	ResourcesComplete int
+	ResourcesTotal    int
+}
+
+// WaitOpts tailors how the waiters in this package report progress.
+type WaitOpts struct {
+	// OnProgress, if set, is called on each poll of the stack.
+	OnProgress func(Progress)
+	// CountResources makes each poll also list the resources of the stack to
+	// fill in the resource counts of Progress. It costs an extra request per
+	// poll and is ignored if OnProgress is nil.
+	CountResources bool
+}
+
+// WaitForStatus polls a stack until it reaches the given status. It returns
+// an ErrStackFailed if the stack reaches a *_FAILED status instead or, when
+// status is CREATE_COMPLETE or ADOPT_COMPLETE, settles in any other status
+// such as ROLLBACK_COMPLETE, and an ErrWaitTimeout if the status is not reached within timeout. A timeout of
+// zero or less waits indefinitely. Progress is reported according to opts,
+// of which at most one is used.
+func WaitForStatus(c *gophercloud.ServiceClient, stackName, stackID, status string, timeout time.Duration, opts ...WaitOpts) error {
+	_, err := waitForStatus(context.Background(), c, stackName, stackID, waitTarget{statuses: []string{status}}, timeout, waitOpts(opts))
+	return err
+}
+
+// WaitForStatusContext behaves like WaitForStatus but without a timeout of its
+// own: it stops polling and returns ctx.Err() as soon as ctx is cancelled or
+// its deadline passes, including while sleeping between two polls.
+func WaitForStatusContext(ctx context.Context, c *gophercloud.ServiceClient, stackName, stackID, status string, opts ...WaitOpts) error {
+	_, err := waitForStatus(ctx, c, stackName, stackID, waitTarget{statuses: []string{status}}, 0, waitOpts(opts))
+	return err
+}
+
+func waitOpts(opts []WaitOpts) WaitOpts {
+	if len(opts) == 0 {
+		return WaitOpts{}
+	}
+	return opts[0]
+}
+
+// waitTarget is what waitForStatus polls a stack for.
+type waitTarget struct {
+	// statuses ends the wait as soon as the stack reaches any of them.
+	statuses []string
+	// transient are *_FAILED statuses the stack is expected to go through on
+	// its way to one of statuses, which don't fail the wait.
+	transient []string
+	// check, if set, is called with the stack on each poll that neither
+	// reached nor failed, and ends the wait when it returns true or an error.
+	check func(*RetrievedStack) (bool, error)
+	// description replaces statuses in the ErrWaitTimeout, if set.
+	description string
+}
+
+func (t waitTarget) reached(status string) bool {
+	return containsStatus(t.statuses, status)
+}
+
+func (t waitTarget) failed(status string) bool {
+	if containsStatus(t.transient, status) {
+		return false
+	}
+	if strings.HasSuffix(status, "_FAILED") {
+		return true
+	}
+	// A create or adopt that settles in any other status, e.g. in
+	// ROLLBACK_COMPLETE after rolling back, will never reach its target.
+	return t.awaitsCreation() && settled(status)
+}
+
+// awaitsCreation reports whether t waits for a stack to be created or
+// adopted.
+func (t waitTarget) awaitsCreation() bool {
+	return t.reached(StatusCreateComplete) || t.reached(StatusAdoptComplete)
+}
+
+// settled reports whether status is one a stack stays in until it is acted
+// upon again.
+func settled(status string) bool {
+	return !strings.HasSuffix(status, "_IN_PROGRESS") && !strings.HasPrefix(status, "INIT_")
+}
+
+func (t waitTarget) String() string {
+	if t.description != "" {
+		return t.description
+	}
+	return strings.Join(t.statuses, " or ")
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForStatus(ctx context.Context, c *gophercloud.ServiceClient, stackName, stackID string, target waitTarget, timeout time.Duration, opts WaitOpts) (*RetrievedStack, error) {
+	var stack *RetrievedStack
+	err := polling.Poll(ctx, PollInterval, timeout, func() (bool, error) {
+		var err error
+		stack, err = Get(c, stackName, stackID).Extract()
+		if err != nil {
+			return false, err
+		}
+
+		if opts.OnProgress != nil {
+			progress := Progress{Status: stack.Status}
+			if opts.CountResources {
+				progress.ResourcesComplete, progress.ResourcesTotal, err = countResources(c, stackName, stackID, 0)
+				if err != nil {
+					return false, err
+				}
+			}
+			opts.OnProgress(progress)
+		}
+
+		if target.reached(stack.Status) {
+			return true, nil
+		}
+		if target.failed(stack.Status) {
+			return false, ErrStackFailed{StackName: stackName, StackID: stackID, Status: stack.Status, Reason: stack.StatusReason}
+		}
+		if target.check != nil {
+			return target.check(stack)
+		}
+		return false, nil
+	}, func() error {
+		return ErrWaitTimeout{StackName: stackName, StackID: stackID, Status: target.String(), LastStatus: stack.Status}
+	})
+	return stack, err
+}
+
+// ResourceProgress estimates how far along a stack is from the ratio of its
+// resources in a *_COMPLETE status, done, to all its resources, total. It is
+// only an approximation, as resources differ widely in how long they take.
+// Resources of nested stacks are counted as well up to depth levels of
+// nesting; a depth of zero counts only the resources of the stack itself.
+func ResourceProgress(c *gophercloud.ServiceClient, stackName, stackID string, depth int) (done, total int, err error) {
+	return countResources(c, stackName, stackID, depth)
+}
+
+// countResources returns the number of resources of a stack in a *_COMPLETE
+// status and its total number of resources, down to the given nested depth.
+func countResources(c *gophercloud.ServiceClient, stackName, stackID string, depth int) (complete, total int, err error) {
+	resources, err := stackresources.ExtractAll(c, stackName, stackID, stackresources.ListOpts{Depth: depth})
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, resource := range resources {
+		if strings.HasSuffix(resource.Status, "_COMPLETE") {
+			complete++
+		}
+	}
+	return complete, len(resources), nil
+}
+
+// CreateAndWait creates a stack and waits for it to reach CREATE_COMPLETE,
+// returning the fully populated stack. If the stack fails to create, the
+// returned ErrStackFailed carries the failure reason and, when they can be
+// retrieved, the most recent events of the stack to aid debugging.
+func CreateAndWait(c *gophercloud.ServiceClient, opts CreateOptsBuilder, timeout time.Duration) (RetrievedStack, error) {
+	if err := checkEndpoint(c); err != nil {
+		return RetrievedStack{}, err
+	}
+	b, err := opts.ToStackCreateMap()
+	if err != nil {
+		return RetrievedStack{}, err
+	}
+	stackName, _ := b["stack_name"].(string)
+
+	stackID, err := create(c, opts, b).StackID()
+	if err != nil {
+		return RetrievedStack{}, err
+	}
+
+	return waitWithEvents(c, stackName, stackID, StatusCreateComplete, timeout)
+}
+
+// AdoptAndWait adopts a stack and waits for it to reach ADOPT_COMPLETE,
+// returning the fully populated stack. If the adoption fails, the returned
+// ErrStackFailed carries the failure reason and, when they can be retrieved,
+// the most recent events of the stack.
+func AdoptAndWait(c *gophercloud.ServiceClient, opts AdoptOptsBuilder, timeout time.Duration) (RetrievedStack, error) {
+	if err := checkEndpoint(c); err != nil {
+		return RetrievedStack{}, err
+	}
+	b, err := opts.ToStackAdoptMap()
+	if err != nil {
+		return RetrievedStack{}, err
+	}
+	stackName, _ := b["stack_name"].(string)
+
+	stackID, err := adopt(c, opts, b).StackID()
+	if err != nil {
+		return RetrievedStack{}, err
+	}
+
+	return waitWithEvents(c, stackName, stackID, StatusAdoptComplete, timeout)
+}
+
+// waitWithEvents waits for a stack to reach status, attaching the most recent
+// events of the stack to the ErrStackFailed returned if it fails.
+func waitWithEvents(c *gophercloud.ServiceClient, stackName, stackID, status string, timeout time.Duration) (RetrievedStack, error) {
+	stack, err := waitForStatus(context.Background(), c, stackName, stackID, waitTarget{statuses: []string{status}}, timeout, WaitOpts{})
+	if err != nil {
+		if failed, ok := err.(ErrStackFailed); ok {
+			failed.Events = lastEvents(c, stackName, stackID, failureEventCount)
+			err = failed
+		}
+		if stack == nil {
+			return RetrievedStack{}, err
+		}
+		return *stack, err
+	}
+	return *stack, nil
+}
+
+// CancelUpdateAndWait cancels an in-progress update of a stack and waits for
+// the stack to be rolled back, i.e. to reach ROLLBACK_COMPLETE, or
+// UPDATE_COMPLETE if the update completed before Heat could cancel it. The
+// UPDATE_FAILED status Heat reports for the cancelled update on the way is
+// not a failure; ROLLBACK_FAILED is, and is returned as an ErrStackFailed. An
+// ErrWaitTimeout is returned if the rollback doesn't complete within timeout.
+func CancelUpdateAndWait(c *gophercloud.ServiceClient, stackName, stackID string, timeout time.Duration) error {
+	if err := CancelUpdate(c, stackName, stackID).ExtractErr(); err != nil {
+		return err
+	}
+	target := waitTarget{
+		statuses:  []string{StatusRollbackComplete, StatusUpdateComplete},
+		transient: []string{StatusUpdateFailed},
+	}
+	_, err := waitForStatus(context.Background(), c, stackName, stackID, target, timeout, WaitOpts{})
+	return err
+}
+
+// WaitForOutput polls a stack until the output with the given key has a
+// non-empty value and returns it, e.g. to retrieve an address that is only
+// known once some resources are created. Values that are not strings are
+// returned as JSON. It returns an ErrStackFailed if the stack reaches a
+// *_FAILED status, and an ErrWaitTimeout if the output has no value within
+// timeout; a timeout of zero or less waits indefinitely. Once the stack is in
+// a *_COMPLETE status, a missing or unresolvable output is returned as an
+// ErrOutputNotFound or ErrOutputError right away.
+func WaitForOutput(c *gophercloud.ServiceClient, stackName, stackID, outputKey string, timeout time.Duration) (string, error) {
+	return waitForOutput(context.Background(), c, stackName, stackID, outputKey, timeout)
+}
+
+// WaitForOutputContext behaves like WaitForOutput but without a timeout of its
+// own: it stops polling and returns ctx.Err() as soon as ctx is cancelled or
+// its deadline passes.
+func WaitForOutputContext(ctx context.Context, c *gophercloud.ServiceClient, stackName, stackID, outputKey string) (string, error) {
+	return waitForOutput(ctx, c, stackName, stackID, outputKey, 0)
+}
+
+func waitForOutput(ctx context.Context, c *gophercloud.ServiceClient, stackName, stackID, outputKey string, timeout time.Duration) (string, error) {
+	var value string
+	target := waitTarget{
+		check: func(stack *RetrievedStack) (bool, error) {
+			output, err := stack.Output(outputKey)
+			if err == nil && output.Value != nil && output.Value != "" {
+				if s, ok := output.Value.(string); ok {
+					value = s
+					return true, nil
+				}
+				b, err := json.Marshal(output.Value)
+				value = string(b)
+				return true, err
+			}
+			if err != nil && strings.HasSuffix(stack.Status, "_COMPLETE") {
+				return false, err
+			}
+			return false, nil
+		},
+		description: "a value for output " + outputKey,
+	}
+	if _, err := waitForStatus(ctx, c, stackName, stackID, target, timeout, WaitOpts{}); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Snapshot statuses reported by Heat.
+const (
+	SnapshotStatusInProgress = "IN_PROGRESS"
+	SnapshotStatusComplete   = "COMPLETE"
+	SnapshotStatusFailed     = "FAILED"
+)
+
+// UpdateWithSnapshot takes a snapshot of a stack, waits for it to complete and
+// then updates the stack. The snapshot ID is returned so the caller can
+// restore the stack if the update fails. If the snapshot cannot be taken, the
+// update is not attempted and the returned UpdateResult holds the error; an
+// ErrSnapshotFailed is returned if the snapshot reaches the FAILED status,
+// and an ErrWaitTimeout if it does not complete within SnapshotWaitTimeout.
+func UpdateWithSnapshot(c *gophercloud.ServiceClient, stackName, stackID string, opts UpdateOptsBuilder) (snapshotID string, r UpdateResult) {
+	snapshot, err := Snapshot(c, stackName, stackID, SnapshotOpts{}).Extract()
+	if err != nil {
+		r.Err = err
+		return
+	}
+	snapshotID = snapshot.ID
+
+	polled := false
+	err = polling.Poll(context.Background(), PollInterval, SnapshotWaitTimeout, func() (bool, error) {
+		// The snapshot returned by Snapshot is checked before polling.
+		if polled {
+			current, err := GetSnapshot(c, stackName, stackID, snapshotID).Extract()
+			if err != nil {
+				return false, err
+			}
+			snapshot = current
+		}
+		polled = true
+
+		switch snapshot.Status {
+		case SnapshotStatusComplete:
+			return true, nil
+		case SnapshotStatusFailed:
+			return false, ErrSnapshotFailed{SnapshotID: snapshotID, Status: snapshot.Status, Reason: snapshot.StatusReason}
+		}
+		return false, nil
+	}, func() error {
+		return ErrWaitTimeout{StackName: stackName, StackID: stackID, Status: "snapshot " + snapshotID + " " + SnapshotStatusComplete, LastStatus: snapshot.Status}
+	})
+	if err != nil {
+		r.Err = err
+		return
+	}
+
+	r = Update(c, stackName, stackID, opts)
+	return
+}
+
+// lastEvents returns up to n of the most recent events of a stack. Errors are
+// ignored since the events are only used to enrich another error.
+func lastEvents(c *gophercloud.ServiceClient, stackName, stackID string, n int) []stackevents.Event {
+	events, err := stackevents.ExtractAll(c, stackName, stackID, nil)
+	if err != nil {
+		return nil
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	if len(events) > n {
+		events = events[:n]
+	}
+	return events
+}
@@ -2,6 +2,8 @@ package stacktemplates
 
 import (
 	"encoding/json"
+	"sort"
+	"strings"
 
 	"github.com/gophercloud/gophercloud"
 )
@@ -28,6 +30,10 @@ type ValidatedTemplate struct {
 	Description     string                 `json:"Description"`
 	Parameters      map[string]interface{} `json:"Parameters"`
 	ParameterGroups map[string]interface{} `json:"ParameterGroups"`
+	// Warnings are non-fatal issues found in the template, such as the use of
+	// deprecated resource types. They are only set by deployments reporting
+	// them; issues making the template invalid are returned as errors.
+	Warnings []string `json:"Warnings"`
 }
 
 // ValidateResult represents the result of a Validate operation.
@@ -42,3 +48,99 @@ func (r ValidateResult) Extract() (*ValidatedTemplate, error) {
 	err := r.ExtractInto(&s)
 	return s, err
 }
+
+// ParamSchema represents the schema of a single template parameter, as
+// reported in the Parameters of a Validate response.
+type ParamSchema struct {
+	Type          string        `json:"Type"`
+	Default       interface{}   `json:"Default"`
+	Description   string        `json:"Description"`
+	Label         string        `json:"Label"`
+	AllowedValues []interface{} `json:"AllowedValues"`
+	// NoEcho is true if the value of the parameter is sensitive, e.g. a
+	// password, and should be masked. Heat reports it as a "true" or "false"
+	// string, which is converted.
+	NoEcho bool `json:"NoEcho"`
+	// Immutable is true if the parameter cannot be changed on stack update.
+	// It is left false by deployments that don't report it.
+	Immutable bool `json:"Immutable"`
+	// Constraints holds the constraints of the parameter keyed as Heat
+	// reports them, e.g. AllowedValues, AllowedPattern, MinLength or
+	// CustomConstraint. It is nil if the parameter has none.
+	Constraints map[string]interface{} `json:"-"`
+}
+
+// parameterConstraintKeys are the keys of a validated parameter that hold its
+// constraints.
+var parameterConstraintKeys = []string{
+	"AllowedValues",
+	"AllowedPattern",
+	"MinLength",
+	"MaxLength",
+	"MinValue",
+	"MaxValue",
+	"CustomConstraint",
+	"ConstraintDescription",
+}
+
+func (r *ParamSchema) UnmarshalJSON(b []byte) error {
+	type tmp ParamSchema
+	var s struct {
+		tmp
+		NoEcho interface{} `json:"NoEcho"`
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*r = ParamSchema(s.tmp)
+
+	switch noEcho := s.NoEcho.(type) {
+	case bool:
+		r.NoEcho = noEcho
+	case string:
+		r.NoEcho = strings.EqualFold(noEcho, "true")
+	}
+
+	for _, k := range parameterConstraintKeys {
+		if v, ok := raw[k]; ok {
+			if r.Constraints == nil {
+				r.Constraints = make(map[string]interface{})
+			}
+			r.Constraints[k] = v
+		}
+	}
+
+	return nil
+}
+
+// ExtractParameters returns the schema of every parameter of the validated
+// template, keyed by parameter name.
+func (r ValidateResult) ExtractParameters() (map[string]ParamSchema, error) {
+	var s struct {
+		Parameters map[string]ParamSchema `json:"Parameters"`
+	}
+	err := r.ExtractInto(&s)
+	return s.Parameters, err
+}
+
+// ImmutableParameters returns the sorted names of the parameters that cannot
+// be changed on stack update. It returns nil if the result holds an error.
+func (r ValidateResult) ImmutableParameters() []string {
+	params, err := r.ExtractParameters()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for name, param := range params {
+		if param.Immutable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
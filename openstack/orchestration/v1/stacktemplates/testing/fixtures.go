@@ -81,6 +81,106 @@ const ValidateOutput = `
 	}
 }`
 
+// ValidateImmutableOutput represents the response body from a Validate request
+// against a deployment reporting immutable parameters.
+const ValidateImmutableOutput = `
+{
+	"Description": "Simple template to test heat commands",
+	"Parameters": {
+		"flavor": {
+			"Default": "m1.tiny",
+			"Type": "String",
+			"NoEcho": "false",
+			"Description": "",
+			"Label": "flavor",
+			"Immutable": false
+		},
+		"network": {
+			"Type": "String",
+			"NoEcho": "false",
+			"Description": "",
+			"Label": "network",
+			"Immutable": true
+		}
+	}
+}`
+
+// ValidateImmutableExpected represents the expected parameter schemas from a
+// Validate request using ValidateImmutableOutput.
+var ValidateImmutableExpected = map[string]stacktemplates.ParamSchema{
+	"flavor": {
+		Type:    "String",
+		Default: "m1.tiny",
+		Label:   "flavor",
+	},
+	"network": {
+		Type:      "String",
+		Label:     "network",
+		Immutable: true,
+	},
+}
+
+// ValidateParametersOutput represents the response body from a Validate
+// request for a template with constrained and sensitive parameters.
+const ValidateParametersOutput = `
+{
+	"Description": "Simple template to test heat commands",
+	"Parameters": {
+		"flavor": {
+			"Default": "m1.tiny",
+			"Type": "String",
+			"NoEcho": "false",
+			"Description": "Flavor of the server",
+			"Label": "flavor",
+			"AllowedValues": ["m1.tiny", "m1.small"]
+		},
+		"db_password": {
+			"Type": "String",
+			"NoEcho": "true",
+			"Description": "Database password",
+			"Label": "db_password",
+			"MinLength": 8,
+			"ConstraintDescription": "At least 8 characters"
+		}
+	}
+}`
+
+// ValidateParametersExpected represents the expected parameters from a
+// Validate request using ValidateParametersOutput.
+var ValidateParametersExpected = map[string]stacktemplates.ParamSchema{
+	"flavor": {
+		Type:          "String",
+		Description:   "Flavor of the server",
+		Default:       "m1.tiny",
+		Label:         "flavor",
+		AllowedValues: []interface{}{"m1.tiny", "m1.small"},
+		Constraints: map[string]interface{}{
+			"AllowedValues": []interface{}{"m1.tiny", "m1.small"},
+		},
+	},
+	"db_password": {
+		Type:        "String",
+		Description: "Database password",
+		Label:       "db_password",
+		Constraints: map[string]interface{}{
+			"MinLength":             float64(8),
+			"ConstraintDescription": "At least 8 characters",
+		},
+		NoEcho: true,
+	},
+}
+
+// ValidateWarningsOutput represents the response body from a Validate request
+// against a deployment reporting a deprecated resource type.
+const ValidateWarningsOutput = `
+{
+	"Description": "Simple template to test heat commands",
+	"Parameters": {},
+	"Warnings": [
+		"OS::Nova::FloatingIP is DEPRECATED: Use OS::Neutron::FloatingIP instead."
+	]
+}`
+
 // HandleValidateSuccessfully creates an HTTP handler at `/validate`
 // on the test handler mux that responds with a `Validate` response.
 func HandleValidateSuccessfully(t *testing.T, output string) {
@@ -56,3 +56,60 @@ func TestValidateTemplate(t *testing.T) {
 	expected := ValidateExpected
 	th.AssertDeepEquals(t, expected, actual)
 }
+
+func TestValidateTemplateImmutableParameters(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleValidateSuccessfully(t, ValidateImmutableOutput)
+
+	opts := stacktemplates.ValidateOpts{
+		TemplateURL: "http://www.example.com/template.yaml",
+	}
+	res := stacktemplates.Validate(fake.ServiceClient(), opts)
+
+	params, err := res.ExtractParameters()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, ValidateImmutableExpected, params)
+	th.AssertDeepEquals(t, []string{"network"}, res.ImmutableParameters())
+}
+
+func TestValidateTemplateNoImmutableParameters(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleValidateSuccessfully(t, ValidateOutput)
+
+	opts := stacktemplates.ValidateOpts{
+		TemplateURL: "http://www.example.com/template.yaml",
+	}
+	params, err := stacktemplates.Validate(fake.ServiceClient(), opts).ExtractParameters()
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, false, params["flavor"].Immutable)
+}
+
+func TestValidateTemplateParameters(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleValidateSuccessfully(t, ValidateParametersOutput)
+
+	opts := stacktemplates.ValidateOpts{
+		TemplateURL: "http://www.example.com/template.yaml",
+	}
+	params, err := stacktemplates.Validate(fake.ServiceClient(), opts).ExtractParameters()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, ValidateParametersExpected, params)
+}
+
+func TestValidateTemplateWarnings(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleValidateSuccessfully(t, ValidateWarningsOutput)
+
+	opts := stacktemplates.ValidateOpts{
+		TemplateURL: "http://www.example.com/template.yaml",
+	}
+	actual, err := stacktemplates.Validate(fake.ServiceClient(), opts).Extract()
+	th.AssertNoErr(t, err)
+	th.AssertDeepEquals(t, []string{
+		"OS::Nova::FloatingIP is DEPRECATED: Use OS::Neutron::FloatingIP instead.",
+	}, actual.Warnings)
+}
@@ -0,0 +1,56 @@
+package gophercloud
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles the requests sent by a ServiceClient. Wait blocks
+// until a request may be sent, or returns an error if it may not.
+//
+// *rate.Limiter from golang.org/x/time/rate satisfies this interface.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimiter returns a RateLimiter that spaces requests evenly so that at
+// most requestsPerSecond requests are sent per second. It returns nil, meaning
+// no limiting, if requestsPerSecond is not positive.
+func NewRateLimiter(requestsPerSecond float64) RateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	return &intervalLimiter{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -215,6 +215,26 @@ func (r Result) PrettyPrintJSON() string {
 	return string(pretty)
 }
 
+// ExtractRaw returns the response body as a generic JSON object, giving access
+// to fields that aren't modeled by the typed Extract methods. A body that has
+// already been decoded is returned as-is rather than being decoded again, so
+// the returned map shares its contents with Body.
+func (r Result) ExtractRaw() (map[string]interface{}, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Body == nil {
+		return nil, nil
+	}
+	if m, ok := r.Body.(map[string]interface{}); ok {
+		return m, nil
+	}
+
+	var m map[string]interface{}
+	err := r.ExtractInto(&m)
+	return m, err
+}
+
 // ErrResult is an internal type to be used by individual resource packages, but
 // its methods will be available on a wide variety of user-facing embedding
 // types.
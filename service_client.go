@@ -1,9 +1,11 @@
 package gophercloud
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // ServiceClient stores details required to interact with a specific service API implemented by a provider.
@@ -30,8 +32,35 @@ type ServiceClient struct {
 	Microversion string
 
 	// MoreHeaders allows users (or Gophercloud) to set service-wide headers on requests. Put another way,
-	// values set in this field will be set on all the HTTP requests the service client sends.
+	// values set in this field will be set on all the HTTP requests the service client sends, unless
+	// the request sets the same header itself.
 	MoreHeaders map[string]string
+
+	// RequestTimeout bounds each individual HTTP request sent by the service
+	// client, including reading the response. It is unrelated to any timeout
+	// of the resources being operated on. Zero means no client-imposed limit.
+	RequestTimeout time.Duration
+
+	// BeforeRequest, if set, is called before each request sent by the service
+	// client, e.g. to start a tracing span.
+	BeforeRequest func(method, url string)
+
+	// AfterRequest, if set, is called after each request sent by the service
+	// client with the HTTP status code of the response, or 0 if no response
+	// was received, and the error returned by the request, if any.
+	AfterRequest func(method, url string, status int, err error)
+
+	// RateLimiter, if set, is waited on before each request sent by the
+	// service client, e.g. to avoid tripping the rate limits of the service
+	// during bulk operations. See NewRateLimiter. Nil means no limiting.
+	RateLimiter RateLimiter
+
+	// OkCodes, if set, gives the HTTP status codes interpreted as success for
+	// the requests of the given HTTP method, e.g. {"DELETE": {200, 204}}, for
+	// deployments diverging from the upstream API. It only applies to
+	// requests that don't set their own OkCodes, which keep the codes expected
+	// by their operation.
+	OkCodes map[string][]int
 }
 
 // ResourceBaseURL returns the base URL of any resources used by this service. It MUST end with a /.
@@ -138,13 +167,46 @@ func (client *ServiceClient) setMicroversionHeader(opts *RequestOpts) {
 
 // Request carries out the HTTP operation for the service client
 func (client *ServiceClient) Request(method, url string, options *RequestOpts) (*http.Response, error) {
+	// The settings of the client are applied to a copy of options, so that
+	// options reused across requests or clients are left untouched.
+	opts := new(RequestOpts)
+	if options != nil {
+		*opts = *options
+	}
+	options = opts
 	if len(client.MoreHeaders) > 0 {
-		if options == nil {
-			options = new(RequestOpts)
-		}
+		headers := make(map[string]string, len(client.MoreHeaders)+len(options.MoreHeaders))
 		for k, v := range client.MoreHeaders {
-			options.MoreHeaders[k] = v
+			headers[k] = v
+		}
+		for k, v := range options.MoreHeaders {
+			headers[k] = v
+		}
+		options.MoreHeaders = headers
+	}
+	if options.Timeout == 0 {
+		options.Timeout = client.RequestTimeout
+	}
+	if codes, ok := client.OkCodes[method]; ok && options.OkCodes == nil {
+		options.OkCodes = codes
+	}
+
+	if client.RateLimiter != nil {
+		if err := client.RateLimiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if client.BeforeRequest != nil {
+		client.BeforeRequest(method, url)
+	}
+	resp, err := client.ProviderClient.Request(method, url, options)
+	if client.AfterRequest != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
 		}
+		client.AfterRequest(method, url, status, err)
 	}
-	return client.ProviderClient.Request(method, url, options)
+	return resp, err
 }
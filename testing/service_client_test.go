@@ -1,9 +1,11 @@
 package testing
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gophercloud/gophercloud"
 	th "github.com/gophercloud/gophercloud/testhelper"
@@ -32,3 +34,186 @@ func TestMoreHeaders(t *testing.T) {
 	th.AssertNoErr(t, err)
 	th.AssertEquals(t, resp.Request.Header.Get("custom"), "header")
 }
+
+func TestMoreHeadersRequestPrecedence(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c := new(gophercloud.ServiceClient)
+	c.MoreHeaders = map[string]string{
+		"custom": "client",
+		"other":  "client",
+	}
+	c.ProviderClient = new(gophercloud.ProviderClient)
+	resp, err := c.Get(fmt.Sprintf("%s/route", th.Endpoint()), nil, &gophercloud.RequestOpts{
+		MoreHeaders: map[string]string{"custom": "request"},
+	})
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "request", resp.Request.Header.Get("custom"))
+	th.AssertEquals(t, "client", resp.Request.Header.Get("other"))
+}
+
+func TestRequestOptsReusedAcrossClients(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	a := new(gophercloud.ServiceClient)
+	a.ProviderClient = new(gophercloud.ProviderClient)
+	a.MoreHeaders = map[string]string{"client": "a"}
+	a.RequestTimeout = 5 * time.Second
+	a.OkCodes = map[string][]int{"GET": {200, 203}}
+	b := new(gophercloud.ServiceClient)
+	b.ProviderClient = new(gophercloud.ProviderClient)
+
+	opts := &gophercloud.RequestOpts{MoreHeaders: map[string]string{"custom": "request"}}
+	resp, err := a.Get(fmt.Sprintf("%s/route", th.Endpoint()), nil, opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "a", resp.Request.Header.Get("client"))
+
+	resp, err = b.Get(fmt.Sprintf("%s/route", th.Endpoint()), nil, opts)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, "", resp.Request.Header.Get("client"))
+	th.AssertEquals(t, "request", resp.Request.Header.Get("custom"))
+
+	th.AssertEquals(t, 1, len(opts.MoreHeaders))
+	th.AssertEquals(t, time.Duration(0), opts.Timeout)
+	th.AssertEquals(t, 0, len(opts.OkCodes))
+}
+
+func TestRequestTimeout(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c := new(gophercloud.ServiceClient)
+	c.ProviderClient = new(gophercloud.ProviderClient)
+	c.RequestTimeout = 20 * time.Millisecond
+	_, err := c.Get(fmt.Sprintf("%s/slow", th.Endpoint()), nil, nil)
+	if err == nil {
+		t.Fatal("expected the request to time out")
+	}
+
+	// A per-request timeout takes precedence over the service client one.
+	_, err = c.Get(fmt.Sprintf("%s/slow", th.Endpoint()), nil, &gophercloud.RequestOpts{Timeout: 5 * time.Second})
+	th.AssertNoErr(t, err)
+
+	c.RequestTimeout = 0
+	_, err = c.Get(fmt.Sprintf("%s/slow", th.Endpoint()), nil, nil)
+	th.AssertNoErr(t, err)
+	th.AssertEquals(t, time.Duration(0), c.HTTPClient.Timeout)
+}
+
+func TestRequestHooks(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	th.Mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	var calls []string
+	c := new(gophercloud.ServiceClient)
+	c.ProviderClient = new(gophercloud.ProviderClient)
+	c.BeforeRequest = func(method, url string) {
+		calls = append(calls, fmt.Sprintf("before %s %s", method, url))
+	}
+	c.AfterRequest = func(method, url string, status int, err error) {
+		calls = append(calls, fmt.Sprintf("after %s %s %d %t", method, url, status, err != nil))
+	}
+
+	_, err := c.Get(th.Endpoint()+"ok", nil, nil)
+	th.AssertNoErr(t, err)
+	_, err = c.Delete(th.Endpoint()+"missing", nil)
+	if err == nil {
+		t.Fatal("expected a 404 error")
+	}
+
+	th.AssertDeepEquals(t, []string{
+		"before GET " + th.Endpoint() + "ok",
+		"after GET " + th.Endpoint() + "ok 200 false",
+		"before DELETE " + th.Endpoint() + "missing",
+		"after DELETE " + th.Endpoint() + "missing 404 true",
+	}, calls)
+}
+
+func TestRateLimiter(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c := new(gophercloud.ServiceClient)
+	c.ProviderClient = new(gophercloud.ProviderClient)
+	c.RateLimiter = gophercloud.NewRateLimiter(20)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(th.Endpoint()+"ok", nil, nil)
+		th.AssertNoErr(t, err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expected 3 requests at 20/s to take at least 100ms, took %s", elapsed)
+	}
+}
+
+type rejectingLimiter struct{}
+
+func (rejectingLimiter) Wait(ctx context.Context) error {
+	return context.DeadlineExceeded
+}
+
+func TestRateLimiterError(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not have been sent")
+	})
+
+	c := new(gophercloud.ServiceClient)
+	c.ProviderClient = new(gophercloud.ProviderClient)
+	c.RateLimiter = rejectingLimiter{}
+
+	_, err := c.Get(th.Endpoint()+"ok", nil, nil)
+	th.AssertEquals(t, context.DeadlineExceeded, err)
+	if gophercloud.NewRateLimiter(0) != nil {
+		t.Fatal("expected no limiter for a non-positive rate")
+	}
+}
+
+func TestOkCodesOverride(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/accepted", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	c := new(gophercloud.ServiceClient)
+	c.ProviderClient = new(gophercloud.ProviderClient)
+
+	_, err := c.Post(th.Endpoint()+"accepted", nil, nil, nil)
+	if err == nil {
+		t.Fatal("expected a 200 to be rejected")
+	}
+
+	c.OkCodes = map[string][]int{"POST": {200, 202}}
+	_, err = c.Post(th.Endpoint()+"accepted", nil, nil, nil)
+	th.AssertNoErr(t, err)
+
+	// The codes of an operation take precedence over those of the client.
+	_, err = c.Post(th.Endpoint()+"accepted", nil, nil, &gophercloud.RequestOpts{OkCodes: []int{202}})
+	if err == nil {
+		t.Fatal("expected a 200 to be rejected by the codes of the request")
+	}
+}